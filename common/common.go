@@ -1,12 +1,31 @@
 package common
 
-// Auth is the type with ws connection infomations
+import (
+	"errors"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+)
+
+// Auth is the type with ws connection infomations,
+// Signer signs instead of Secret if not nil
 type Auth struct {
 	Address string
 	Secret  string
+	Signer  crypto.Signer
 	Owner   string
 }
 
+// KeySigner return the signer of the operating account
+func (a *Auth) KeySigner() (crypto.Signer, error) {
+	if a.Signer != nil {
+		return a.Signer, nil
+	}
+	if a.Secret == "" {
+		return nil, errors.New("no secret or signer of the operating account")
+	}
+	return crypto.NewSecretSigner(a.Secret)
+}
+
 //IRequest define interface for request
 type IRequest interface {
 	GetID() int64
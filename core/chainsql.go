@@ -1,21 +1,47 @@
 package core
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/ChainSQL/go-chainsql-api/crypto"
 	. "github.com/ChainSQL/go-chainsql-api/data"
 	"github.com/ChainSQL/go-chainsql-api/export"
+	"github.com/ChainSQL/go-chainsql-api/keystore"
 	"github.com/ChainSQL/go-chainsql-api/net"
 	"github.com/ChainSQL/go-chainsql-api/util"
+	"github.com/buger/jsonparser"
 )
 
 // Chainsql is the interface struct for this package
 type Chainsql struct {
 	client *net.Client
+	op     *tableListOp
+	tran   *sqlTran
 	SubmitBase
 }
 
+// tableListOp is the table operation prepared by Chainsql
+type tableListOp struct {
+	opType  uint16
+	name    string
+	newName string
+	raw     string
+	user    string
+	flags   TransactionFlag
+	tran    *sqlTran
+	schema  Transaction
+	err     error
+
+	// confidential and publicKey are for CreateTableWithOptions and GrantWithPublicKey
+	confidential bool
+	publicKey    string
+}
+
 type TableGetSqlJSON struct {
 	Account     string
 	Sql         string
@@ -28,6 +54,7 @@ func NewChainsql() *Chainsql {
 		client: net.NewClient(),
 	}
 	chainsql.SubmitBase.client = chainsql.client
+	chainsql.SubmitBase.tokens = newTableTokens()
 	chainsql.SubmitBase.IPrepare = chainsql
 	return chainsql
 }
@@ -36,38 +63,332 @@ func NewChainsql() *Chainsql {
 func (c *Chainsql) As(address string, secret string) {
 	c.client.Auth.Address = address
 	c.client.Auth.Secret = secret
+	c.client.Auth.Signer = nil
+	c.tokens.reset()
+
+	if c.client.Auth.Owner == "" {
+		c.client.Auth.Owner = address
+	}
+}
+
+// AsSigner specify the operating account signing by signer,
+// like crypto.CommandSigner keeping the key out of the process
+func (c *Chainsql) AsSigner(address string, signer crypto.Signer) {
+	c.client.Auth.Address = address
+	c.client.Auth.Secret = ""
+	c.client.Auth.Signer = signer
+	c.tokens.reset()
 
 	if c.client.Auth.Owner == "" {
 		c.client.Auth.Owner = address
 	}
 }
 
+// AsKeystore specify the operating account kept in the keystore of path,
+// the account is unlocked by password for timeout, or until the process exits
+// if timeout is 0. Signing after the timeout fails with keystore.ErrLocked,
+// call AsKeystore again to unlock it
+func (c *Chainsql) AsKeystore(path string, address string, password string, timeout time.Duration) error {
+	ks, err := keystore.New(path)
+	if err != nil {
+		return err
+	}
+	err = ks.Unlock(address, password, timeout)
+	if err != nil {
+		return err
+	}
+	c.AsSigner(address, ks.Signer(address))
+	return nil
+}
+
 // Use specify the table owner
 func (c *Chainsql) Use(owner string) {
 	c.client.Auth.Owner = owner
 }
 
 // PrepareTx prepare tx json for submit
-func (c *Chainsql) PrepareTx() (Signer, error) {
+func (c *Chainsql) PrepareTx(ctx context.Context, offline *OfflineParams) (Signer, error) {
+	return c.prepareTx(c.newTxPreparer(ctx, offline))
+}
+
+func (c *Chainsql) prepareTx(p *txPreparer) (Signer, error) {
+	if c.op == nil {
+		return nil, errors.New("No operation to submit")
+	}
+	if c.op.err != nil {
+		return nil, c.op.err
+	}
+	if c.op.tran != nil {
+		return c.prepareSQLTransaction(p)
+	}
+	if c.op.schema != nil {
+		return c.prepareSchemaTx(p)
+	}
+	return c.prepareTableListSet(p)
+}
+
+func (c *Chainsql) prepareTableListSet(p *txPreparer) (*TableListSet, error) {
+	account, err := NewAccountFromAddress(c.client.Auth.Address)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := p.accountSequence()
+	if err != nil {
+		return nil, err
+	}
+	switch c.op.opType {
+	case util.TCreate, util.TDrop, util.TRename:
+		// the table created again under the name may be confidential or not
+		p.tokens.forget(c.client.Auth.Address, c.op.name, c.op.newName)
+	}
+	var nameInDB string
+	if c.op.opType == util.TCreate {
+		nameInDB, err = p.newNameInDB(c.op.name)
+	} else {
+		nameInDB, err = p.tableNameInDB(c.client.Auth.Address, c.op.name)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	log.Println("Chainsql prepareTx")
 	tx := &TableListSet{}
+	tx.TransactionType = TABLE_LIST_SET
+	tx.OpType = c.op.opType
+	if c.op.opType == util.TRename {
+		tx.Tables = FormatTablesForRename(c.op.name, nameInDB, c.op.newName)
+	} else {
+		tx.Tables = FormatTables(c.op.name, nameInDB)
+	}
+	if c.op.raw != "" {
+		valRaw := VariableLength(c.op.raw)
+		tx.Raw = &valRaw
+	}
+	if c.op.opType == util.TGrant {
+		user, err := NewAccountFromAddress(c.op.user)
+		if err != nil {
+			return nil, err
+		}
+		flags := c.op.flags
+		tx.User = user
+		tx.Flags = &flags
+	}
+	tx.Token, err = c.prepareTableToken(p)
+	if err != nil {
+		return nil, err
+	}
+	tx.Account = *account
+	tx.Sequence = seq
+	err = p.prepareLastLedgerAndFee(&tx.TxBase, util.GetExtraFee(c.op.raw, c.client.ServerInfo.Snapshot().DropsPerByte))
+	if err != nil {
+		return nil, err
+	}
 	return tx, nil
 }
 
-//Table create a new table object
+// prepareTableToken generate the token of a confidential table to create
+// wrapped for the operating account, or wrap the token for the user to grant
+func (c *Chainsql) prepareTableToken(p *txPreparer) (*VariableLength, error) {
+	switch {
+	case c.op.opType == util.TCreate && c.op.confidential:
+		token, err := crypto.NewToken()
+		if err != nil {
+			return nil, err
+		}
+		signer, err := c.client.Auth.KeySigner()
+		if err != nil {
+			return nil, err
+		}
+		publicKey, err := signer.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		p.tokens.set(tokenKey{TableKey{c.client.Auth.Address, c.op.name}, c.client.Auth.Address}, token)
+		return wrapToken(token, publicKey)
+	case c.op.opType == util.TGrant && c.op.flags != 0:
+		if c.op.publicKey != "" {
+			// the public key is only needed to grant a confidential table
+			p.tokens.mark(c.client.Auth.Address, c.op.name)
+		}
+		token, err := p.tableToken(c.client.Auth.Address, c.op.name)
+		if err != nil || token == nil {
+			return nil, err
+		}
+		if c.op.publicKey == "" {
+			return nil, fmt.Errorf("the public key of %s is required to grant the confidential table %s", c.op.user, c.op.name)
+		}
+		publicKey, err := parsePublicKey(c.op.publicKey)
+		if err != nil {
+			return nil, err
+		}
+		return wrapToken(token, publicKey)
+	default:
+		return nil, nil
+	}
+}
+
+//CreateTable create a table, parameter schemaJSON is a json-array string like
+// [{"field":"id","type":"int","PK":1},{"field":"name","type":"varchar","length":50}]
+func (c *Chainsql) CreateTable(name string, schemaJSON string) *Chainsql {
+	c.op = &tableListOp{
+		opType: util.TCreate,
+		name:   name,
+		raw:    schemaJSON,
+	}
+	if !json.Valid([]byte(schemaJSON)) {
+		c.op.err = fmt.Errorf("invalid table schema %s", schemaJSON)
+	}
+	return c
+}
+
+//DropTable drop a table created by the operating account
+func (c *Chainsql) DropTable(name string) *Chainsql {
+	c.op = &tableListOp{
+		opType: util.TDrop,
+		name:   name,
+	}
+	return c
+}
+
+//RenameTable rename a table created by the operating account
+func (c *Chainsql) RenameTable(oldName string, newName string) *Chainsql {
+	c.op = &tableListOp{
+		opType:  util.TRename,
+		name:    oldName,
+		newName: newName,
+	}
+	return c
+}
+
+//Table create a new table object, the insert/update/delete operations created
+//on the table between BeginTran and CommitTran are put into the transaction,
+//whenever the table is got, and are not submitted on their own
 func (c *Chainsql) Table(name string) *Table {
-	return NewTable(name, c.client)
+	table := NewTable(name, c.client)
+	table.chainsql = c
+	table.retry = c.retry
+	table.tokens = c.tokens
+	return table
+}
+
+//Connect is used to create connections to one or several nodes,
+//requests go to the healthiest node and fail over to the others.
+//http:// and https:// urls use the JSON-RPC port, txs are polled for
+//their result there and table subscriptions are not supported
+func (c *Chainsql) Connect(urls ...string) error {
+	return c.client.Connect(urls...)
+}
+
+//ConnectContext is the same as Connect but the connecting is bounded by ctx
+func (c *Chainsql) ConnectContext(ctx context.Context, urls ...string) error {
+	return c.client.ConnectContext(ctx, urls...)
+}
+
+//ConnectWithOptions is the same as ConnectContext with the CA pool, client
+//certificates, headers, proxy and timeouts in opts, nil opts uses the defaults
+func (c *Chainsql) ConnectWithOptions(ctx context.Context, opts *net.DialOptions, urls ...string) error {
+	return c.client.ConnectWithOptions(ctx, opts, urls...)
+}
+
+//Nodes return the health of the connected nodes
+func (c *Chainsql) Nodes() []net.NodeStatus {
+	return c.client.Nodes()
+}
+
+//Grant grant the authorities of a table created by the operating account to user,
+//parameter flagsJSON is a json-object string like
+// {"select":true,"insert":true,"update":false,"delete":false}
+//the authorities not specified in flagsJSON are revoked
+func (c *Chainsql) Grant(tableName string, user string, flagsJSON string) *Chainsql {
+	c.op = &tableListOp{
+		opType: util.TGrant,
+		name:   tableName,
+		user:   user,
+	}
+	c.op.flags, c.op.err = parseGrantFlags(flagsJSON)
+	return c
+}
+
+//Revoke revoke all the authorities of a table from user
+func (c *Chainsql) Revoke(tableName string, user string) *Chainsql {
+	return c.Grant(tableName, user, "{}")
+}
+
+func parseGrantFlags(flagsJSON string) (TransactionFlag, error) {
+	var auth map[string]bool
+	err := json.Unmarshal([]byte(flagsJSON), &auth)
+	if err != nil {
+		return 0, fmt.Errorf("invalid grant flags %s:%s", flagsJSON, err)
+	}
+	var flags TransactionFlag
+	for name, enabled := range auth {
+		var flag TransactionFlag
+		switch name {
+		case "select":
+			flag = TxTableSelect
+		case "insert":
+			flag = TxTableInsert
+		case "update":
+			flag = TxTableUpdate
+		case "delete":
+			flag = TxTableDelete
+		case "execute":
+			flag = TxTableExecute
+		default:
+			return 0, fmt.Errorf("unknown grant flag %s", name)
+		}
+		if enabled {
+			flags |= flag
+		}
+	}
+	return flags, nil
 }
 
-//Connect is used to create a websocket connection
-func (c *Chainsql) Connect(url string) error {
-	return c.client.Connect(url)
+//GetTableAuth request for the users granted on a table and their authorities,
+//all the granted users will be returned if no account is specified
+func (c *Chainsql) GetTableAuth(owner string, tableName string, accounts ...string) (string, error) {
+	return c.client.GetTableAuth(owner, tableName, accounts)
 }
 
-// GetLedger request a ledger
+//GetTableAuthContext is the same as GetTableAuth but bounded by ctx
+func (c *Chainsql) GetTableAuthContext(ctx context.Context, owner string, tableName string, accounts ...string) (string, error) {
+	return c.client.GetTableAuthContext(ctx, owner, tableName, accounts)
+}
+
+// GetLedger request a ledger, the error is returned in json
+// like {"status":"error","error_message":"..."}
 func (c *Chainsql) GetLedger(seq int) string {
-	return c.client.GetLedger(seq)
+	ledger, err := c.GetLedgerContext(context.Background(), seq)
+	if err != nil {
+		msg, _ := json.Marshal(map[string]string{
+			"status":        "error",
+			"error_message": err.Error(),
+		})
+		return string(msg)
+	}
+	return ledger
+}
+
+// GetLedgerContext is the same as GetLedger but bounded by ctx
+func (c *Chainsql) GetLedgerContext(ctx context.Context, seq int) (string, error) {
+	ledger, err := c.GetLedgerResultContext(ctx, seq)
+	if err != nil {
+		return "", err
+	}
+	return ledger.String()
+}
+
+// GetLedgerResult request a ledger and return it parsed
+func (c *Chainsql) GetLedgerResult(seq int) (*LedgerResult, error) {
+	return c.GetLedgerResultContext(context.Background(), seq)
+}
+
+// GetLedgerResultContext is the same as GetLedgerResult but bounded by ctx
+func (c *Chainsql) GetLedgerResultContext(ctx context.Context, seq int) (*LedgerResult, error) {
+	response, err := c.client.GetLedgerContext(ctx, seq)
+	if err != nil {
+		return nil, err
+	}
+	return NewLedgerResult(response)
 }
 
 //OnLedgerClosed reponses in callback functor
@@ -75,6 +396,48 @@ func (c *Chainsql) OnLedgerClosed(callback export.Callback) {
 	c.client.Event.SubscribeLedger(callback)
 }
 
+//OnLedgerClosedEvent is the same as OnLedgerClosed but got the parsed message in callback
+func (c *Chainsql) OnLedgerClosedEvent(callback func(*LedgerClosedEvent)) {
+	c.client.Event.SubscribeLedger(func(msg string) {
+		event := &LedgerClosedEvent{}
+		err := json.Unmarshal([]byte(msg), event)
+		if err != nil {
+			log.Printf("OnLedgerClosedEvent error:%s\n", err)
+			return
+		}
+		callback(event)
+	})
+}
+
+//SubscribeTable subscribe the changes of a table,
+//callback is triggered with the table message when a transaction on the table is validated
+func (c *Chainsql) SubscribeTable(owner string, name string, callback export.Callback) error {
+	return c.client.SubscribeTable(name, owner, callback)
+}
+
+//SubscribeTableContext is the same as SubscribeTable but bounded by ctx
+func (c *Chainsql) SubscribeTableContext(ctx context.Context, owner string, name string, callback export.Callback) error {
+	return c.client.SubscribeTableContext(ctx, name, owner, callback)
+}
+
+//SubscribeTableEvent is the same as SubscribeTable but got the parsed message in callback
+func (c *Chainsql) SubscribeTableEvent(owner string, name string, callback func(*TableEvent)) error {
+	return c.SubscribeTable(owner, name, func(msg string) {
+		event := &TableEvent{}
+		err := json.Unmarshal([]byte(msg), event)
+		if err != nil {
+			log.Printf("SubscribeTableEvent error:%s\n", err)
+			return
+		}
+		callback(event)
+	})
+}
+
+//UnsubscribeTable cancel the subscription of a table
+func (c *Chainsql) UnsubscribeTable(owner string, name string) error {
+	return c.client.UnSubscribeTable(name, owner)
+}
+
 // GenerateAccount generate an account with the format:
 // {
 //		"address":"zxY4HEbEDSivZwouzwzqHQBA9QbJYdqDTg",
@@ -82,12 +445,24 @@ func (c *Chainsql) OnLedgerClosed(callback export.Callback) {
 //		"publicKeyHex":"02EA30B2A25844D4AFBAF6020DA9C9FED573AA0058791BFC8642E69888693CF8EA",
 //		"privateKey":"xniMQKhxZTMbfWb8scjRPXa5Zv6HB",
 // }
-func (c *Chainsql) GenerateAccount(args ...string) (string, error) {
-	if len(args) == 0 {
-		return crypto.GenerateAccount()
-	} else {
-		return crypto.GenerateAccount(args[0])
-	}
+// crypto.WithSecret recreates the account of a secret,
+// crypto.WithAlgorithm(crypto.Ed25519) or crypto.WithAlgorithm(crypto.SoftGM)
+// generates an account of another algorithm than ECDSA
+func (c *Chainsql) GenerateAccount(opts ...crypto.AccountOption) (string, error) {
+	return crypto.GenerateAccount(opts...)
+}
+
+// GenerateAccountFromSecret recreate the account of secret,
+// it is kept for the callers of the former GenerateAccount(secret).
+//
+// Deprecated: use GenerateAccount(crypto.WithSecret(secret))
+func (c *Chainsql) GenerateAccountFromSecret(secret string) (string, error) {
+	return crypto.GenerateAccountFromSecret(secret)
+}
+
+// GenerateAccountKeys is the same as GenerateAccount but return the account parsed
+func (c *Chainsql) GenerateAccountKeys(opts ...crypto.AccountOption) (*AccountKeys, error) {
+	return crypto.GenerateAccountKeys(opts...)
 }
 
 //SignPlainData sign a plain text and return the signature
@@ -100,68 +475,169 @@ func (c *Chainsql) GetNameInDB(address string, tableName string) (string, error)
 	return c.client.GetNameInDB(address, tableName)
 }
 
+//GetNameInDBContext is the same as GetNameInDB but bounded by ctx
+func (c *Chainsql) GetNameInDBContext(ctx context.Context, address string, tableName string) (string, error) {
+	return c.client.GetNameInDBContext(ctx, address, tableName)
+}
+
 //GetBySqlUser is used to select from database by sql
 func (c *Chainsql) GetBySqlUser(sql string) (string, error) {
+	return c.GetBySqlUserContext(context.Background(), sql)
+}
+
+//GetBySqlUserContext is the same as GetBySqlUser but bounded by ctx
+func (c *Chainsql) GetBySqlUserContext(ctx context.Context, sql string) (string, error) {
 	data := &TableGetSqlJSON{
 		Account: c.client.Auth.Address,
 		Sql:     sql,
 	}
-	if c.client.ServerInfo.Updated {
-		data.LedgerIndex = c.client.ServerInfo.LedgerIndex
-	} else {
-		ledgerIndex, err := c.client.GetLedgerVersion()
-		if err != nil {
-			return "", err
-		}
-		data.LedgerIndex = ledgerIndex
+	ledgerIndex, err := getLedgerIndex(ctx, c.client)
+	if err != nil {
+		return "", err
 	}
-	return c.client.GetTableData(data, true)
+	data.LedgerIndex = ledgerIndex
+	return c.client.GetTableDataContext(ctx, data, true)
 }
 
-func (c *Chainsql) IsConnected() bool {
-	if c.client.GetWebocketManager() != nil {
-		return c.client.GetWebocketManager().IsConnected()
+//GetBySqlUserRowsContext is the same as GetBySqlUserRows but bounded by ctx
+func (c *Chainsql) GetBySqlUserRowsContext(ctx context.Context, sql string) (*TableRows, error) {
+	result, err := c.GetBySqlUserContext(ctx, sql)
+	if err != nil {
+		return nil, err
 	}
-	return false
+	return NewTableRows(result)
+}
+
+//GetBySqlUserRows is the same as GetBySqlUser but return the rows parsed
+func (c *Chainsql) GetBySqlUserRows(sql string) (*TableRows, error) {
+	return c.GetBySqlUserRowsContext(context.Background(), sql)
+}
+
+func (c *Chainsql) IsConnected() bool {
+	return c.client.IsConnected()
 }
 
 func (c *Chainsql) Disconnect() {
-	if c.client.GetWebocketManager() != nil {
-		c.client.GetWebocketManager().Disconnect()
-	}
+	c.client.Disconnect()
 }
 
 func (c *Chainsql) ValidationCreate() (string, error) {
 	return crypto.ValidationCreate()
 }
 
+//GetServerInfo request for the status of the connected node,
+//the result of server_info is returned in json
 func (c *Chainsql) GetServerInfo() (string, error) {
-	return "", nil
+	return c.GetServerInfoContext(context.Background())
+}
+
+//GetServerInfoContext is the same as GetServerInfo but bounded by ctx
+func (c *Chainsql) GetServerInfoContext(ctx context.Context) (string, error) {
+	response, err := c.client.GetServerInfoContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return rawResult(response)
+}
+
+//GetServerInfoResult is the same as GetServerInfo but return the status parsed
+func (c *Chainsql) GetServerInfoResult() (*net.NodeInfo, error) {
+	return c.GetServerInfoResultContext(context.Background())
 }
 
+//GetServerInfoResultContext is the same as GetServerInfoResult but bounded by ctx
+func (c *Chainsql) GetServerInfoResultContext(ctx context.Context) (*net.NodeInfo, error) {
+	response, err := c.client.GetServerInfoContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return net.NewNodeInfo(response)
+}
+
+//GetAccountInfo request for the balance, sequence and flags of an account,
+//the result of account_info is returned in json
 func (c *Chainsql) GetAccountInfo(address string) (string, error) {
-	return crypto.GetAccountInfo(address)
+	return c.GetAccountInfoContext(context.Background(), address)
 }
 
+//GetAccountInfoContext is the same as GetAccountInfo but bounded by ctx
+func (c *Chainsql) GetAccountInfoContext(ctx context.Context, address string) (string, error) {
+	response, err := c.client.GetAccountInfoContext(ctx, address)
+	if err != nil {
+		return "", err
+	}
+	return rawResult(response)
+}
+
+//GetAccountInfoResult is the same as GetAccountInfo but return the account parsed
+func (c *Chainsql) GetAccountInfoResult(address string) (*net.AccountInfo, error) {
+	return c.GetAccountInfoResultContext(context.Background(), address)
+}
+
+//GetAccountInfoResultContext is the same as GetAccountInfoResult but bounded by ctx
+func (c *Chainsql) GetAccountInfoResultContext(ctx context.Context, address string) (*net.AccountInfo, error) {
+	response, err := c.client.GetAccountInfoContext(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return net.NewAccountInfo(response)
+}
+
+// rawResult return the result of a response in json
+func rawResult(response string) (string, error) {
+	result, _, _, err := jsonparser.Get([]byte(response), "result")
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// newRipple creates a Ripple sharing the connection and the retry policy
+func (c *Chainsql) newRipple() *Ripple {
+	r := NewRipple(c.client)
+	r.retry = c.retry
+	return r
+}
+
+//Pay pay to accountId with the operating account, value is the decimal amount
+//of ZXC like "1.5", or an issued currency like "10/USD/zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh"
 func (c *Chainsql) Pay(accountId string, value string) *Ripple {
-	r := NewRipple()
-	return r.Pay(accountId, value)
+	return c.newRipple().Pay(accountId, value)
 }
 
-func (c *Chainsql) CreateSchema(schemaInfo string) *Chainsql {
+//PayDrops pay drops of ZXC to accountId with the operating account
+func (c *Chainsql) PayDrops(accountId string, drops int64) *Ripple {
+	return c.newRipple().PayDrops(accountId, drops)
+}
 
-	return c
+//TrustSet create or modify a trust line of the operating account,
+//limit is like "1000/USD/zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh"
+func (c *Chainsql) TrustSet(limit string) *Ripple {
+	return c.newRipple().TrustSet(limit)
 }
 
-func (c *Chainsql) ModifySchema(schemaInfo string) *Chainsql {
+//AuthorizeTrust authorize the trust line of holder on currency issued by the operating account
+func (c *Chainsql) AuthorizeTrust(holder string, currency string) *Ripple {
+	return c.newRipple().AuthorizeTrust(holder, currency)
+}
 
-	return c
+//SetDefaultRipple enable or disable rippling on the trust lines of the operating account by default
+func (c *Chainsql) SetDefaultRipple(enable bool) *Ripple {
+	return c.newRipple().SetDefaultRipple(enable)
 }
 
-func (c *Chainsql) GetSchemaList(params string) (string, error) {
-	return "", nil
+//SetRequireAuth require or not the operating account to authorize the trust lines to it
+func (c *Chainsql) SetRequireAuth(enable bool) *Ripple {
+	return c.newRipple().SetRequireAuth(enable)
 }
 
-func (c *Chainsql) UpdateSchemaConfig(params string) *Chainsql {
-	return c
+//SignerListSet set the multi-signers of the operating account with their weights,
+//quorum 0 and no signers deletes the signer list
+func (c *Chainsql) SignerListSet(quorum uint32, signers map[string]uint16) *Ripple {
+	return c.newRipple().SignerListSet(quorum, signers)
+}
+
+//SetTransferRate set the fee rate charged when users transfer the currencies issued by the operating account
+func (c *Chainsql) SetTransferRate(rate string) *Ripple {
+	return c.newRipple().SetTransferRate(rate)
 }
@@ -0,0 +1,250 @@
+package core
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/ChainSQL/go-chainsql-api/common"
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+	. "github.com/ChainSQL/go-chainsql-api/data"
+	"github.com/ChainSQL/go-chainsql-api/net"
+)
+
+// TableOptions are the options of CreateTableWithOptions
+type TableOptions struct {
+	// Confidential encrypts the operations on the table with a random token,
+	// which is wrapped with the public key of each user granted on the table
+	Confidential bool
+}
+
+//CreateTableWithOptions is the same as CreateTable with the options in opts.
+//The whole raw of Insert, Update and Delete on a confidential table is
+//encrypted with its token, which the nodes decrypt to run the statements,
+//and the lines returned by Get are decrypted with the token the same way
+func (c *Chainsql) CreateTableWithOptions(name string, schemaJSON string, opts *TableOptions) *Chainsql {
+	c.CreateTable(name, schemaJSON)
+	if opts != nil {
+		c.op.confidential = opts.Confidential
+	}
+	return c
+}
+
+//GrantWithPublicKey is the same as Grant, the token of a confidential table is
+//wrapped with publicKey of user, in hex or base58 like GenerateAccount returns
+func (c *Chainsql) GrantWithPublicKey(tableName string, user string, publicKey string, flagsJSON string) *Chainsql {
+	c.Grant(tableName, user, flagsJSON)
+	c.op.publicKey = publicKey
+	return c
+}
+
+//GetUserToken request for the token of a confidential table wrapped for user,
+//it is empty if the table is not confidential. A table with a token is marked
+//confidential, so the operations on it are encrypted from then on
+func (c *Chainsql) GetUserToken(owner string, tableName string, user string) (string, error) {
+	token, err := c.client.GetUserToken(owner, tableName, user)
+	if err == nil && token != "" {
+		c.tokens.mark(owner, tableName)
+	}
+	return token, err
+}
+
+// TableKey is a table by its owner address and name
+type TableKey struct {
+	Owner string
+	Name  string
+}
+
+// tokenKey is the token of a table wrapped for user
+type tokenKey struct {
+	TableKey
+	user string
+}
+
+// tableToken return the token of the table owned by owner for the operating
+// account, nil if the table is not confidential
+func (p *txPreparer) tableToken(owner string, name string) ([]byte, error) {
+	if p.offline != nil {
+		return unwrapToken(p.client.Auth, p.offline.Tokens[TableKey{owner, name}])
+	}
+	return p.tokens.get(p.ctx, p.client, owner, name)
+}
+
+// tableTokens keeps the tables known to be confidential, created by
+// CreateTableWithOptions or marked by GetUserToken, and caches their tokens
+// by the operating user. The tokens of the other tables are never requested
+type tableTokens struct {
+	mutex        sync.Mutex
+	confidential map[TableKey]bool
+	tokens       map[tokenKey][]byte
+}
+
+func newTableTokens() *tableTokens {
+	return &tableTokens{
+		confidential: make(map[TableKey]bool),
+		tokens:       make(map[tokenKey][]byte),
+	}
+}
+
+// get return the token of the table for the operating account of client,
+// nil if the table is not known to be confidential or has no token for it
+func (tt *tableTokens) get(ctx context.Context, client *net.Client, owner string, name string) ([]byte, error) {
+	if tt == nil {
+		return nil, nil
+	}
+	key := tokenKey{TableKey{owner, name}, client.Auth.Address}
+	tt.mutex.Lock()
+	confidential := tt.confidential[key.TableKey]
+	token, ok := tt.tokens[key]
+	tt.mutex.Unlock()
+	if !confidential || ok {
+		return token, nil
+	}
+	wrapped, err := client.GetUserTokenContext(ctx, owner, name, key.user)
+	if err != nil || wrapped == "" {
+		return nil, err
+	}
+	token, err = unwrapToken(client.Auth, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	tt.set(key, token)
+	return token, nil
+}
+
+// set cache the token of a table for a user and mark the table confidential
+func (tt *tableTokens) set(key tokenKey, token []byte) {
+	if tt == nil {
+		return
+	}
+	tt.mutex.Lock()
+	tt.confidential[key.TableKey] = true
+	tt.tokens[key] = token
+	tt.mutex.Unlock()
+}
+
+// mark the table confidential, its token is requested by the next operation
+func (tt *tableTokens) mark(owner string, name string) {
+	if tt == nil {
+		return
+	}
+	tt.mutex.Lock()
+	tt.confidential[TableKey{owner, name}] = true
+	tt.mutex.Unlock()
+}
+
+// forget drop the table and its tokens, newName takes the table over when renamed
+func (tt *tableTokens) forget(owner string, name string, newName string) {
+	if tt == nil {
+		return
+	}
+	table := TableKey{owner, name}
+	tt.mutex.Lock()
+	defer tt.mutex.Unlock()
+	if newName != "" && tt.confidential[table] {
+		tt.confidential[TableKey{owner, newName}] = true
+	}
+	delete(tt.confidential, table)
+	for key := range tt.tokens {
+		if key.TableKey == table {
+			delete(tt.tokens, key)
+		}
+	}
+}
+
+// reset drop the tokens unwrapped by the account replaced
+func (tt *tableTokens) reset() {
+	if tt == nil {
+		return
+	}
+	tt.mutex.Lock()
+	tt.tokens = make(map[tokenKey][]byte)
+	tt.mutex.Unlock()
+}
+
+// unwrapToken decrypt the wrapped token in hex with the key of the operating account
+func unwrapToken(auth *common.Auth, wrapped string) ([]byte, error) {
+	if wrapped == "" {
+		return nil, nil
+	}
+	data, err := hex.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("invalid table token %s:%s", wrapped, err)
+	}
+	signer, err := auth.KeySigner()
+	if err != nil {
+		return nil, err
+	}
+	decrypter, ok := signer.(crypto.Decrypter)
+	if !ok {
+		return nil, fmt.Errorf("the signer %T can not decrypt the table token", signer)
+	}
+	token, err := decrypter.Decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(token) != crypto.TokenLength {
+		return nil, fmt.Errorf("wrong table token length %d", len(token))
+	}
+	return token, nil
+}
+
+// wrapToken encrypt token to publicKey for the Token of TableListSet
+func wrapToken(token []byte, publicKey []byte) (*VariableLength, error) {
+	wrapped, err := crypto.EncryptTo(publicKey, token)
+	if err != nil {
+		return nil, err
+	}
+	valToken := VariableLength(wrapped)
+	return &valToken, nil
+}
+
+// parsePublicKey parse an account public key in hex or base58
+func parsePublicKey(publicKey string) ([]byte, error) {
+	if key, err := hex.DecodeString(publicKey); err == nil {
+		return key, nil
+	}
+	hash, err := crypto.NewRippleHashCheck(publicKey, crypto.RIPPLE_ACCOUNT_PUBLIC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %s:%s", publicKey, err)
+	}
+	return hash.Payload(), nil
+}
+
+// encryptRaw encrypt the whole raw with the token of a confidential table
+func encryptRaw(token []byte, raw string) (string, error) {
+	encrypted, err := crypto.TokenEncrypt(token, []byte(raw))
+	if err != nil {
+		return "", err
+	}
+	return string(encrypted), nil
+}
+
+// decryptLines decrypt the lines in the result of r_get, the lines of a
+// confidential table come as the hex of the whole json array encrypted
+func decryptLines(token []byte, result string) (string, error) {
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		return "", err
+	}
+	var lines string
+	if json.Unmarshal(response["lines"], &lines) != nil {
+		return result, nil
+	}
+	encrypted, err := hex.DecodeString(lines)
+	if err != nil {
+		return "", fmt.Errorf("the lines are not encrypted:%s", err)
+	}
+	plain, err := crypto.TokenDecrypt(token, encrypted)
+	if err != nil {
+		return "", fmt.Errorf("decrypt the lines:%s", err)
+	}
+	response["lines"] = json.RawMessage(plain)
+	decrypted, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(decrypted), nil
+}
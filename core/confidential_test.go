@@ -0,0 +1,201 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+	. "github.com/ChainSQL/go-chainsql-api/data"
+)
+
+// signOfflineTx sign the tx offline and decode it
+func signOfflineTx(t *testing.T, submit *SubmitBase, params *OfflineParams) Transaction {
+	signed, err := submit.SignOffline(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, _, err := parseSignedTx(signed.TxBlob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// decryptRaw decrypt the whole raw encrypted with token
+func decryptRaw(t *testing.T, token []byte, raw *VariableLength) string {
+	decrypted, err := crypto.TokenDecrypt(token, raw.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(decrypted)
+}
+
+func TestConfidentialTable(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+	params := &OfflineParams{Sequence: 1, Fee: 12, NameInDB: "A1B2C3"}
+
+	tx := signOfflineTx(t, &c.CreateTableWithOptions("t1", `[{"field":"id","type":"text"}]`, &TableOptions{Confidential: true}).SubmitBase, params)
+	create := tx.(*TableListSet)
+	if create.Token == nil || create.Raw.String() != fmt.Sprintf("%X", `[{"field":"id","type":"text"}]`) {
+		t.Fatalf("unexpected tx %+v", create)
+	}
+	wrapped := create.Token.String()
+	token, err := unwrapToken(c.client.Auth, wrapped)
+	if err != nil || len(token) != crypto.TokenLength {
+		t.Fatalf("expected the token wrapped for the owner:%v", err)
+	}
+
+	params.Tokens = map[TableKey]string{{testAddress, "t1"}: wrapped}
+	insert := `[{"id":1,"name":"Alice"}]`
+	tx = signOfflineTx(t, &c.Table("t1").Insert(insert).SubmitBase, params)
+	raw := tx.(*SQLStatement).Raw
+	if strings.Contains(string(*raw), "Alice") || decryptRaw(t, token, raw) != insert {
+		t.Fatalf("expected the whole raw encrypted, got %s", raw)
+	}
+	tx = signOfflineTx(t, &c.Table("t1").Update(`{"name":"Bob"}`, `{"id":1}`).SubmitBase, params)
+	if update := decryptRaw(t, token, tx.(*SQLStatement).Raw); update != `[{"name":"Bob"},{"id":1}]` {
+		t.Fatalf("unexpected update %s", update)
+	}
+
+	c.BeginTran()
+	c.Table("t1").Insert(insert)
+	tx = signOfflineTx(t, &c.CommitTran().SubmitBase, params)
+	var statements []Statement
+	if err := json.Unmarshal(tx.(*SQLTransaction).Statements, &statements); err != nil {
+		t.Fatal(err)
+	}
+	if plain := decryptRaw(t, token, statements[0].Raw); plain != insert {
+		t.Fatalf("expected the raw encrypted in a transaction, got %s", plain)
+	}
+
+	// the tables not in Tokens are not confidential
+	params.Tokens = nil
+	tx = signOfflineTx(t, &c.Table("t1").Insert(`[{"id":1}]`).SubmitBase, params)
+	if raw := string(*tx.(*SQLStatement).Raw); raw != `[{"id":1}]` {
+		t.Fatalf("unexpected raw %s", raw)
+	}
+}
+
+func TestConfidentialGrant(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+	token, err := crypto.NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, _ := crypto.NewSecretSigner(testSecret)
+	ownerKey, _ := signer.PublicKey()
+	wrapped, err := crypto.EncryptTo(ownerKey, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := &OfflineParams{Sequence: 1, Fee: 12, NameInDB: "A1B2C3", Tokens: map[TableKey]string{{testAddress, "t1"}: fmt.Sprintf("%X", wrapped)}}
+
+	for _, opt := range []crypto.AccountOption{crypto.WithAlgorithm(crypto.ECDSA), crypto.WithAlgorithm(crypto.SoftGM)} {
+		user, err := crypto.GenerateAccountKeys(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, publicKey := range []string{user.PublicKey, user.PublicKeyHex} {
+			tx := signOfflineTx(t, &c.GrantWithPublicKey("t1", user.Address, publicKey, `{"select":true}`).SubmitBase, params)
+			grant := tx.(*TableListSet)
+			userSigner, _ := crypto.NewSecretSigner(user.PrivateKey)
+			granted, err := userSigner.(crypto.Decrypter).Decrypt(grant.Token.Bytes())
+			if err != nil || !bytes.Equal(granted, token) {
+				t.Fatalf("expected the token wrapped for %s:%v", user.Address, err)
+			}
+		}
+	}
+
+	if _, err := c.Grant("t1", testUser, `{"select":true}`).SignOffline(params); err == nil {
+		t.Fatal("expected granting a confidential table without the public key to fail")
+	}
+	tx := signOfflineTx(t, &c.Revoke("t1", testUser).SubmitBase, params)
+	if tx.(*TableListSet).Token != nil {
+		t.Fatal("expected no token to revoke")
+	}
+	ed25519, _ := crypto.GenerateAccountKeys(crypto.WithAlgorithm(crypto.Ed25519))
+	if _, err := c.GrantWithPublicKey("t1", ed25519.Address, ed25519.PublicKeyHex, `{"select":true}`).SignOffline(params); err == nil {
+		t.Fatal("expected Ed25519 keys to fail")
+	}
+}
+
+func TestConfidentialGet(t *testing.T) {
+	node := newFakeNode(t)
+	defer node.close()
+	c := newTestChainsql(t, node)
+	c.Use(testAddress)
+
+	token, _ := crypto.NewToken()
+	signer, _ := crypto.NewSecretSigner(testSecret)
+	publicKey, _ := signer.PublicKey()
+	wrapped, _ := crypto.EncryptTo(publicKey, token)
+	lines, _ := crypto.TokenEncrypt(token, []byte(`[{"id":1,"name":"Alice","age":null}]`))
+
+	var query string
+	var tokens int32
+	node.onCommand = func(req map[string]interface{}) []interface{} {
+		switch req["command"] {
+		case "g_userToken":
+			atomic.AddInt32(&tokens, 1)
+			return []interface{}{response(req, map[string]interface{}{"token": fmt.Sprintf("%X", wrapped)})}
+		case "g_dbname":
+			return []interface{}{response(req, map[string]interface{}{"nameInDB": "A1B2C3"})}
+		case "r_get":
+			query = req["tx_json"].(map[string]interface{})["Raw"].(string)
+			return []interface{}{response(req, map[string]interface{}{
+				"lines": fmt.Sprintf("%X", lines),
+			})}
+		}
+		return nil
+	}
+
+	// the token of a table not known to be confidential is not requested
+	if _, err := c.Table("t2").Insert(`[{"id":1}]`).PrepareTx(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&tokens); n != 0 {
+		t.Fatalf("expected no token requested, got %d", n)
+	}
+	if _, err := c.GetUserToken(testAddress, "t1", testAddress); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := c.Table("t1").Get(`{"id":1}`).Limit(`{"total":10,"index":0}`).RequestRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != `[[],{"id":1},{"$limit":{"index":0,"total":10}}]` {
+		t.Fatalf("unexpected query %s", query)
+	}
+	var row struct {
+		ID   int
+		Name string
+		Age  *int
+	}
+	if err := rows.Row(0, &row); err != nil || row.ID != 1 || row.Name != "Alice" || row.Age != nil {
+		t.Fatalf("unexpected row %+v:%v", row, err)
+	}
+
+	// the token is cached for the table
+	if _, err := c.Table("t1").Get(`{"id":1}`).RequestRows(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&tokens); n != 2 {
+		t.Fatalf("expected the token requested once after GetUserToken, got %d", n)
+	}
+	// the tokens are wrapped for the operating account
+	c.As(testAddress, testSecret)
+	if _, err := c.Table("t1").Get(`{"id":1}`).RequestRows(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&tokens); n != 3 {
+		t.Fatalf("expected the token requested again by As, got %d", n)
+	}
+}
@@ -0,0 +1,226 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+	. "github.com/ChainSQL/go-chainsql-api/data"
+)
+
+// PrepareMultiSign prepare the tx of the operating account to be multi-signed
+// by signers accounts, the basic fee is raised for each signer as required.
+// The unsigned blob returned is passed to MultiSign of every signer
+func (s *SubmitBase) PrepareMultiSign(signers int) (string, error) {
+	return s.prepareMultiSign(signers, nil)
+}
+
+// PrepareMultiSignOffline is the same as PrepareMultiSign but prepared with
+// params instead of the fields requested from the node
+func (s *SubmitBase) PrepareMultiSignOffline(signers int, params *OfflineParams) (string, error) {
+	if params == nil {
+		return "", errors.New("no offline params to prepare")
+	}
+	return s.prepareMultiSign(signers, params)
+}
+
+func (s *SubmitBase) prepareMultiSign(signers int, params *OfflineParams) (string, error) {
+	if signers <= 0 {
+		return "", errors.New("no signer to multi-sign")
+	}
+	p := s.newTxPreparer(context.Background(), params)
+	p.signers = signers
+	signer, err := s.prepareTx(p)
+	if err != nil {
+		if params == nil {
+			s.client.Sequences.Reset(s.client.Auth.Address)
+		}
+		return "", err
+	}
+	tx, ok := signer.(Transaction)
+	if !ok {
+		return "", fmt.Errorf("%s can not be multi-signed", signer.GetType())
+	}
+	base := tx.GetBase()
+	base.SigningPubKey = new(PublicKey)
+	base.TxnSignature = nil
+	_, blob, err := Raw(tx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%X", blob), nil
+}
+
+// MultiSignBlob sign the blob from PrepareMultiSign as the multi-signer address,
+// credential is the secret or a crypto.Signer of the signer, others fail.
+// The blob with the signature added to Signers is returned,
+// the blobs of all the signers are combined by CombineMultiSigned
+func MultiSignBlob(blob string, address string, credential interface{}) (string, error) {
+	tx, _, err := decodeTx(blob)
+	if err != nil {
+		return "", err
+	}
+	account, err := NewAccountFromAddress(address)
+	if err != nil {
+		return "", err
+	}
+	keySigner, err := crypto.NewSigner(credential)
+	if err != nil {
+		return "", err
+	}
+	signers := tx.GetBase().Signers
+	signer, err := MultiSignWith(tx, keySigner, *account)
+	if err != nil {
+		return "", err
+	}
+	err = SetSigners(tx, append(signers, *signer))
+	if err != nil {
+		return "", err
+	}
+	_, raw, err := Raw(tx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%X", raw), nil
+}
+
+// CombineMultiSigned merge the Signers of the blobs signed by MultiSign
+// into one tx in the order of the signer accounts.
+// The blobs must be signed from the same prepared tx
+func CombineMultiSigned(blobs ...string) (*SignedTx, error) {
+	if len(blobs) == 0 {
+		return nil, errors.New("no blob to combine")
+	}
+	var tx Transaction
+	var unsigned []byte
+	var signers []TxSigner
+	for _, blob := range blobs {
+		signed, _, err := decodeTx(blob)
+		if err != nil {
+			return nil, err
+		}
+		_, data, err := SigningHash(signed)
+		if err != nil {
+			return nil, err
+		}
+		if tx == nil {
+			tx = signed
+			unsigned = data
+		} else if string(data) != string(unsigned) {
+			return nil, errors.New("the blobs are not signed from the same tx")
+		}
+		for i := range signed.GetBase().Signers {
+			signer := &signed.GetBase().Signers[i]
+			ok, err := CheckMultiSignature(signed, signer)
+			if err != nil || !ok {
+				return nil, fmt.Errorf("invalid signature of %s", signer.Signer.Account)
+			}
+			if !containsSigner(signers, signer.Signer.Account) {
+				signers = append(signers, *signer)
+			}
+		}
+	}
+	if len(signers) == 0 {
+		return nil, errors.New("the blobs are not multi-signed")
+	}
+	err := SetSigners(tx, signers)
+	if err != nil {
+		return nil, err
+	}
+	_, raw, err := Raw(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedTx{
+		TxBlob: fmt.Sprintf("%X", raw),
+		Hash:   fmt.Sprintf("%X", tx.GetHash().Bytes()),
+	}, nil
+}
+
+func containsSigner(signers []TxSigner, account Account) bool {
+	for _, signer := range signers {
+		if signer.Signer.Account.Equals(account) {
+			return true
+		}
+	}
+	return false
+}
+
+// SubmitMultiSigned submit a blob combined by CombineMultiSigned
+// with submit_multisigned and wait for the expect status
+func (s *SubmitBase) SubmitMultiSigned(blob string, cond string) *TxResult {
+	ret, _ := s.SubmitMultiSignedContext(context.Background(), blob, cond)
+	return ret
+}
+
+// SubmitMultiSignedContext is the same as SubmitMultiSigned but bounded by ctx
+func (s *SubmitBase) SubmitMultiSignedContext(ctx context.Context, blob string, cond string) (*TxResult, error) {
+	tx, txSigned, err := decodeTx(blob)
+	if err == nil && len(tx.GetBase().Signers) == 0 {
+		err = errors.New("the tx is not multi-signed")
+	}
+	if err == nil {
+		txSigned.txJSON, err = multiSignedJSON(tx)
+	}
+	if err != nil {
+		log.Printf("SubmitMultiSigned error:%s\n", err)
+		return &TxResult{
+			ErrorCode:    "errInvalidBlob",
+			ErrorMessage: err.Error(),
+		}, nil
+	}
+	ret, err := s.handleSignedTx(ctx, txSigned, s.expectFor(cond, tx))
+	// the sequences handed out locally do not know the tx
+	s.client.Sequences.Reset(tx.GetBase().Account.String())
+	return ret, err
+}
+
+// multiSignedJSON is the tx_json of submit_multisigned, without the hash
+func multiSignedJSON(tx Transaction) (map[string]interface{}, error) {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	var txJSON map[string]interface{}
+	err = json.Unmarshal(data, &txJSON)
+	if err != nil {
+		return nil, err
+	}
+	delete(txJSON, "hash")
+	return txJSON, nil
+}
+
+//SignerListSet set the multi-signers of the operating account, signers maps
+//the address of each signer to its weight, a tx needs the signatures of weights
+//adding up to quorum. An empty signers with quorum 0 deletes the signer list
+func (r *Ripple) SignerListSet(quorum uint32, signers map[string]uint16) *Ripple {
+	r.op = &rippleOp{
+		txType:       SIGNER_LIST_SET,
+		signerQuorum: quorum,
+	}
+	addresses := make([]string, 0, len(signers))
+	for address := range signers {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		account, err := NewAccountFromAddress(address)
+		if err != nil {
+			r.setErr(err)
+			return r
+		}
+		weight := signers[address]
+		entry := SignerEntryObj{}
+		entry.SignerEntry.Account = account
+		entry.SignerEntry.SignerWeight = &weight
+		r.op.signerEntries = append(r.op.signerEntries, entry)
+	}
+	if (quorum == 0) != (len(signers) == 0) {
+		r.setErr(errors.New("the quorum must be 0 only to delete the signer list"))
+	}
+	return r
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+	. "github.com/ChainSQL/go-chainsql-api/data"
+	"github.com/ChainSQL/go-chainsql-api/util"
+)
+
+// multiSignTx prepare the tx from submit offline and sign it by each of secrets
+func multiSignTx(t *testing.T, submit *SubmitBase, keys []*crypto.Account) *SignedTx {
+	prepared, err := submit.PrepareMultiSignOffline(len(keys), &OfflineParams{
+		Sequence: 7,
+		Fee:      10,
+		NameInDB: "A1B2C3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blobs []string
+	for _, key := range keys {
+		blob, err := MultiSignBlob(prepared, key.Address, key.PrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blobs = append(blobs, blob)
+	}
+	signed, err := CombineMultiSigned(blobs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func generateSigners(t *testing.T, n int) []*crypto.Account {
+	var keys []*crypto.Account
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateAccountKeys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestMultiSign(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+	keys := generateSigners(t, 3)
+
+	for _, submit := range []*SubmitBase{
+		&c.PayDrops(testUser, 1000).SubmitBase,
+		&c.Table("t1").Insert(`[{"id":1}]`).SubmitBase,
+	} {
+		signed := multiSignTx(t, submit, keys[:2])
+		tx, txSigned, err := decodeTx(signed.TxBlob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		base := tx.GetBase()
+		if txSigned.hash != signed.Hash || base.Account.String() != testAddress || base.Sequence != 7 {
+			t.Fatalf("unexpected tx %+v", base)
+		}
+		if base.TxnSignature != nil || !base.SigningPubKey.IsZero() {
+			t.Fatalf("expected no single signature, got %+v", base)
+		}
+		if len(base.Signers) != 2 || !base.Signers[0].Signer.Account.Less(base.Signers[1].Signer.Account) {
+			t.Fatalf("expected 2 sorted signers, got %+v", base.Signers)
+		}
+		for i := range base.Signers {
+			if ok, err := CheckMultiSignature(tx, &base.Signers[i]); err != nil || !ok {
+				t.Fatalf("invalid signature of %s:%v", base.Signers[i].Signer.Account, err)
+			}
+		}
+		// the basic fee is paid by each signer and the tx, the extra fee once
+		fee := int64(10 * 3)
+		if statement, ok := tx.(*SQLStatement); ok {
+			fee += util.GetExtraFee(string(*statement.Raw), c.client.ServerInfo.Snapshot().DropsPerByte)
+		}
+		if feeDrops(base.Fee) != fee {
+			t.Fatalf("expected the fee %d of 2 signers, got %s", fee, base.Fee)
+		}
+		if _, _, err := parseSignedTx(signed.TxBlob); err == nil {
+			t.Fatal("expected a multi-signed blob not to be submitted by SubmitSigned")
+		}
+	}
+
+	// the blobs of different txs can not be combined
+	prepared1, err := c.PayDrops(testUser, 1).PrepareMultiSignOffline(1, &OfflineParams{Sequence: 1, Fee: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prepared2, err := c.PayDrops(testUser, 2).PrepareMultiSignOffline(1, &OfflineParams{Sequence: 1, Fee: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob1, err := MultiSignBlob(prepared1, keys[0].Address, keys[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob2, err := MultiSignBlob(prepared2, keys[1].Address, keys[1].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CombineMultiSigned(blob1, blob2); err == nil {
+		t.Fatal("expected combining different txs to fail")
+	}
+	if _, err := MultiSignBlob(blob1, keys[0].Address, keys[0].PrivateKey); err == nil {
+		t.Fatal("expected signing twice by a signer to fail")
+	}
+}
+
+func TestSubmitMultiSigned(t *testing.T) {
+	node := newFakeNode(t)
+	defer node.close()
+	requests := make(chan map[string]interface{}, 1)
+	node.onSubmit = func(req map[string]interface{}) []interface{} {
+		requests <- req
+		return []interface{}{response(req, map[string]interface{}{"engine_result": "tesSUCCESS"})}
+	}
+
+	offline := NewChainsql()
+	offline.As(testAddress, testSecret)
+	signed := multiSignTx(t, &offline.PayDrops(testUser, 1000).SubmitBase, generateSigners(t, 2))
+
+	c := NewChainsql()
+	if err := c.Connect(node.url()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	ret := c.SubmitMultiSigned(signed.TxBlob, util.SendSuccess)
+	if ret.Status != util.SendSuccess || ret.TxHash != signed.Hash {
+		t.Fatalf("expected %s of %s, got %+v", util.SendSuccess, signed.Hash, ret)
+	}
+	req := <-requests
+	txJSON, ok := req["tx_json"].(map[string]interface{})
+	if req["command"] != "submit_multisigned" || !ok {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	if signers, ok := txJSON["Signers"].([]interface{}); !ok || len(signers) != 2 || txJSON["SigningPubKey"] != "" {
+		t.Fatalf("unexpected tx_json %+v", txJSON)
+	}
+
+	if ret := c.SubmitMultiSigned("00", util.SendSuccess); ret.ErrorCode != "errInvalidBlob" {
+		t.Fatalf("expected errInvalidBlob, got %+v", ret)
+	}
+}
+
+func TestSignerListSet(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+	keys := generateSigners(t, 2)
+
+	signed, err := c.SignerListSet(2, map[string]uint16{
+		keys[0].Address: 1,
+		keys[1].Address: 1,
+	}).SignOffline(&OfflineParams{Sequence: 3, Fee: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, _, err := parseSignedTx(signed.TxBlob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signerList, ok := tx.(*SignerListSet)
+	if !ok || signerList.SignerQuorum != 2 || len(signerList.SignerEntries) != 2 {
+		t.Fatalf("unexpected tx %+v", tx)
+	}
+	for _, entry := range signerList.SignerEntries {
+		if *entry.SignerEntry.SignerWeight != 1 {
+			t.Fatalf("unexpected entry %+v", entry)
+		}
+	}
+
+	if _, err := c.SignerListSet(0, nil).SignOffline(&OfflineParams{Sequence: 4, Fee: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SignerListSet(0, map[string]uint16{keys[0].Address: 1}).SignOffline(&OfflineParams{Sequence: 4, Fee: 10}); err == nil {
+		t.Fatal("expected quorum 0 with signers to fail")
+	}
+}
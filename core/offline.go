@@ -0,0 +1,224 @@
+package core
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+	. "github.com/ChainSQL/go-chainsql-api/data"
+	"github.com/ChainSQL/go-chainsql-api/net"
+)
+
+// OfflineParams are the fields fetched from the node when submitting,
+// given explicitly to sign a tx without network access
+type OfflineParams struct {
+	// Sequence is the account sequence of the tx
+	Sequence uint32
+	// Fee is the basic fee in drops, the extra fee of chainsql txs
+	// by the size of Raw is added like submitting online
+	Fee int64
+	// LastLedgerSequence is left out of the tx when 0
+	LastLedgerSequence uint32
+	// NameInDB is the NameInDB of the operated table, it is required
+	// for CreateTable as GenerateNameInDB returns with a ledger index
+	NameInDB string
+	// NamesInDB are the NameInDB by table name for a sql transaction
+	// operating several tables, each of them must be in it
+	NamesInDB map[string]string
+	// Tokens are the tokens of the confidential tables operated, wrapped for
+	// the operating account as GetUserToken returns, the tables not in it
+	// are not confidential
+	Tokens map[TableKey]string
+}
+
+// SignedTx is a tx signed offline, TxBlob is submitted by SubmitSigned
+type SignedTx struct {
+	TxBlob string `json:"tx_blob"`
+	Hash   string `json:"hash"`
+}
+
+// nameInDB return the NameInDB of the table name, NameInDB is only
+// used when a single table is operated
+func (p *OfflineParams) nameInDB(name string, tables int) (string, error) {
+	if nameInDB, ok := p.NamesInDB[name]; ok {
+		return nameInDB, nil
+	}
+	if p.NameInDB == "" || tables > 1 {
+		return "", fmt.Errorf("no NameInDB for table %s", name)
+	}
+	return p.NameInDB, nil
+}
+
+// SignOffline prepare and sign the tx with params instead of the fields
+// requested from the node, the operating account is specified by As
+// and no connection is needed
+func (s *SubmitBase) SignOffline(params *OfflineParams) (*SignedTx, error) {
+	if params == nil {
+		return nil, errors.New("no offline params to sign")
+	}
+	if params.Fee <= 0 {
+		return nil, errors.New("the fee to sign offline must be positive")
+	}
+	tx, err := s.prepareTx(s.newTxPreparer(context.Background(), params))
+	if err != nil {
+		return nil, err
+	}
+	txSigned, ret := s.signTx(tx)
+	if ret != nil {
+		return nil, fmt.Errorf("%s:%s", ret.ErrorCode, ret.ErrorMessage)
+	}
+	return &SignedTx{
+		TxBlob: txSigned.blob,
+		Hash:   txSigned.hash,
+	}, nil
+}
+
+// SubmitSigned submit a tx signed by SignOffline and wait for the expect status,
+// the retry policy is not applied since the tx can not be signed again
+func (s *SubmitBase) SubmitSigned(blob string, cond string) *TxResult {
+	ret, _ := s.SubmitSignedContext(context.Background(), blob, cond)
+	return ret
+}
+
+// SubmitSignedContext is the same as SubmitSigned but bounded by ctx
+func (s *SubmitBase) SubmitSignedContext(ctx context.Context, blob string, cond string) (*TxResult, error) {
+	tx, txSigned, err := parseSignedTx(blob)
+	if err != nil {
+		log.Printf("SubmitSigned error:%s\n", err)
+		return &TxResult{
+			ErrorCode:    "errInvalidBlob",
+			ErrorMessage: err.Error(),
+		}, nil
+	}
+	ret, err := s.handleSignedTx(ctx, txSigned, s.expectFor(cond, tx))
+	// the sequences handed out locally do not know the tx
+	s.client.Sequences.Reset(tx.GetBase().Account.String())
+	return ret, err
+}
+
+// parseSignedTx decode a signed blob for its hash and LastLedgerSequence
+func parseSignedTx(blob string) (Transaction, *TxSigned, error) {
+	tx, txSigned, err := decodeTx(blob)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tx.GetBase().TxnSignature == nil {
+		return nil, nil, errors.New("the tx is not signed")
+	}
+	return tx, txSigned, nil
+}
+
+// decodeTx decode a blob, signed or not, and fill its hash
+func decodeTx(blob string) (Transaction, *TxSigned, error) {
+	raw, err := hex.DecodeString(blob)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err := ReadTransaction(strings.NewReader(string(raw)))
+	if err != nil {
+		return nil, nil, err
+	}
+	hash := crypto.Sha512Half(append(HP_TRANSACTION_ID.Bytes(), raw...))
+	copy(tx.GetHash().Bytes(), hash)
+	txSigned := &TxSigned{
+		blob: strings.ToUpper(blob),
+		hash: fmt.Sprintf("%X", hash),
+	}
+	if tx.GetBase().LastLedgerSequence != nil {
+		txSigned.lastLedgerSequence = *tx.GetBase().LastLedgerSequence
+	}
+	return tx, txSigned, nil
+}
+
+// txPreparer request the fields of a tx from the node bounded by ctx,
+// or take them from offline when signing offline
+type txPreparer struct {
+	ctx     context.Context
+	client  *net.Client
+	offline *OfflineParams
+	tokens  *tableTokens
+	// tables is the number of the tables operated by the tx
+	tables int
+	// signers is the number of the multi-signers, 0 for a single-signed tx
+	signers int
+}
+
+func (s *SubmitBase) newTxPreparer(ctx context.Context, offline *OfflineParams) *txPreparer {
+	return &txPreparer{
+		ctx:     ctx,
+		client:  s.client,
+		offline: offline,
+		tokens:  s.tokens,
+		tables:  1,
+	}
+}
+
+// accountSequence return the sequence of the operating account
+func (p *txPreparer) accountSequence() (uint32, error) {
+	if p.offline != nil {
+		return p.offline.Sequence, nil
+	}
+	return net.PrepareAccountContext(p.ctx, p.client)
+}
+
+// tableNameInDB return the NameInDB of the table name owned by owner
+func (p *txPreparer) tableNameInDB(owner string, name string) (string, error) {
+	if p.offline != nil {
+		return p.offline.nameInDB(name, p.tables)
+	}
+	return p.client.GetNameInDBContext(p.ctx, owner, name)
+}
+
+// newNameInDB generate the NameInDB of a table to create
+func (p *txPreparer) newNameInDB(name string) (string, error) {
+	if p.offline != nil {
+		if p.offline.NameInDB == "" {
+			return "", fmt.Errorf("NameInDB is required to create table %s offline", name)
+		}
+		return p.offline.NameInDB, nil
+	}
+	ledgerIndex, err := getLedgerIndex(p.ctx, p.client)
+	if err != nil {
+		return "", err
+	}
+	return GenerateNameInDB(uint32(ledgerIndex), p.client.Auth.Address, name), nil
+}
+
+// prepareTable return the account sequence and the NameInDB of the table name
+func (p *txPreparer) prepareTable(name string) (uint32, string, error) {
+	if p.offline != nil {
+		nameInDB, err := p.offline.nameInDB(name, p.tables)
+		return p.offline.Sequence, nameInDB, err
+	}
+	return net.PrepareTableContext(p.ctx, p.client, name)
+}
+
+// prepareLastLedgerAndFee fills the LastLedgerSequence and Fee of tx,
+// from the offline params when signing offline. extraFee is added to the
+// basic fee once, which is paid by each of the multi-signers and the tx
+func (p *txPreparer) prepareLastLedgerAndFee(tx *TxBase, extraFee int64) error {
+	var fee int64
+	if p.offline == nil {
+		var err error
+		fee, err = prepareLastLedger(p.ctx, p.client, tx)
+		if err != nil {
+			return err
+		}
+	} else {
+		if p.offline.LastLedgerSequence != 0 {
+			last := p.offline.LastLedgerSequence
+			tx.LastLedgerSequence = &last
+		}
+		fee = p.offline.Fee
+	}
+	finalFee, err := NewNativeValue(fee*int64(p.signers+1) + extraFee)
+	if err != nil {
+		return err
+	}
+	tx.Fee = *finalFee
+	return nil
+}
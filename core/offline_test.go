@@ -0,0 +1,242 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+	. "github.com/ChainSQL/go-chainsql-api/data"
+	"github.com/ChainSQL/go-chainsql-api/keystore"
+	"github.com/ChainSQL/go-chainsql-api/util"
+)
+
+func TestSignOffline(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+
+	signed, err := c.Pay(testUser, "1").SignOffline(&OfflineParams{
+		Sequence:           5,
+		Fee:                12,
+		LastLedgerSequence: 200,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, txSigned, err := parseSignedTx(signed.TxBlob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := tx.GetBase()
+	if txSigned.hash != signed.Hash || base.Sequence != 5 || base.Fee.String() != "0.000012" || txSigned.lastLedgerSequence != 200 {
+		t.Fatalf("unexpected tx %+v signed %+v", base, signed)
+	}
+	if base.Account.String() != testAddress {
+		t.Fatalf("unexpected account %s", base.Account)
+	}
+
+	signed, err = c.Table("t1").Insert(`[{"id":1}]`).SignOffline(&OfflineParams{
+		Sequence: 6,
+		Fee:      12,
+		NameInDB: "A1B2C3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, _, err = parseSignedTx(signed.TxBlob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement, ok := tx.(*SQLStatement)
+	if !ok || statement.Sequence != 6 || statement.LastLedgerSequence != nil {
+		t.Fatalf("unexpected tx %+v", tx)
+	}
+
+	if _, err := c.Table("t1").Insert(`[{"id":1}]`).SignOffline(&OfflineParams{Sequence: 6, Fee: 12}); err == nil {
+		t.Fatal("expected the missing NameInDB to fail")
+	}
+	if _, err := c.CreateTable("t1", `[{"field":"id","type":"int"}]`).SignOffline(&OfflineParams{Sequence: 6, Fee: 12, LastLedgerSequence: 200}); err == nil {
+		t.Fatal("expected creating a table without NameInDB to fail")
+	}
+
+	// NameInDB is not used for several tables
+	c.BeginTran()
+	c.Table("t1").Insert(`[{"id":1}]`)
+	c.Table("t2").Insert(`[{"id":1}]`)
+	tran := c.CommitTran()
+	if _, err := tran.SignOffline(&OfflineParams{Sequence: 6, Fee: 12, NameInDB: "A1B2C3"}); err == nil {
+		t.Fatal("expected the tables missing in NamesInDB to fail")
+	}
+	if _, err := tran.SignOffline(&OfflineParams{Sequence: 6, Fee: 12, NamesInDB: map[string]string{"t1": "A1B2C3", "t2": "D4E5F6"}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// countingSigner counts the requests to an in-memory signer
+type countingSigner struct {
+	crypto.Signer
+	signs int
+}
+
+func (c *countingSigner) Sign(hash, msg []byte) ([]byte, error) {
+	c.signs++
+	return c.Signer.Sign(hash, msg)
+}
+
+// TestSignOfflineCredentials sign the same way as the accounts of each key type,
+// a crypto.Signer and a keystore
+func TestSignOfflineCredentials(t *testing.T) {
+	var accounts []*crypto.Account
+	for _, keyType := range []crypto.KeyType{crypto.ECDSA, crypto.Ed25519, crypto.SoftGM} {
+		account, err := crypto.GenerateAccountKeys(crypto.WithAlgorithm(keyType))
+		if err != nil {
+			t.Fatal(err)
+		}
+		accounts = append(accounts, account)
+	}
+	keySigner, err := crypto.NewSecretSigner(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := &countingSigner{Signer: keySigner}
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ks, err := keystore.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks.ScryptN, ks.ScryptP = keystore.LightScryptN, keystore.LightScryptP
+	if _, err := ks.Import(testSecret, "pass"); err != nil {
+		t.Fatal(err)
+	}
+
+	type credential struct {
+		name    string
+		address string
+		// secret is checked by SignPlainData too
+		secret    string
+		publicKey string
+		as        func(c *Chainsql) error
+	}
+	credentials := []credential{
+		{"secret", testAddress, testSecret, "", func(c *Chainsql) error {
+			c.As(testAddress, testSecret)
+			return nil
+		}},
+		{"signer", testAddress, "", "", func(c *Chainsql) error {
+			c.AsSigner(testAddress, signer)
+			return nil
+		}},
+		{"keystore", testAddress, "", "", func(c *Chainsql) error {
+			return c.AsKeystore(dir, testAddress, "pass", 0)
+		}},
+	}
+	for _, account := range accounts {
+		account := account
+		credentials = append(credentials, credential{account.Address, account.Address, account.PrivateKey, account.PublicKeyHex, func(c *Chainsql) error {
+			c.As(account.Address, account.PrivateKey)
+			return nil
+		}})
+	}
+
+	hashes := make(map[string]string)
+	for _, cred := range credentials {
+		c := NewChainsql()
+		if err := cred.as(c); err != nil {
+			t.Fatalf("%s:%s", cred.name, err)
+		}
+		signed, err := c.Pay(testUser, "1").SignOffline(&OfflineParams{Sequence: 1, Fee: 12})
+		if err != nil {
+			t.Fatalf("%s:%s", cred.name, err)
+		}
+		tx, _, err := parseSignedTx(signed.TxBlob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		base := tx.GetBase()
+		if base.Account.String() != cred.address || (cred.publicKey != "" && crypto.B2H(base.SigningPubKey.Bytes()) != cred.publicKey) {
+			t.Fatalf("unexpected %s tx %+v", cred.name, base)
+		}
+		if ok, err := CheckSignature(tx); err != nil || !ok {
+			t.Fatalf("expected the %s signature to be valid:%v", cred.name, err)
+		}
+		// the signatures are deterministic except SM2
+		if hash, ok := hashes[cred.address]; ok && hash != signed.Hash {
+			t.Fatalf("expected the same tx %s signed by %s, got %s", hash, cred.name, signed.Hash)
+		}
+		hashes[cred.address] = signed.Hash
+
+		if cred.secret == "" {
+			continue
+		}
+		signature, err := c.SignPlainData(cred.secret, "HelloWorld")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, _ := crypto.H2B(signature)
+		public := base.SigningPubKey.Bytes()
+		if ok, err := crypto.Verify(public, crypto.Sha512Half([]byte("HelloWorld")), []byte("HelloWorld"), sig); err != nil || !ok {
+			t.Fatalf("expected the %s plain signature to be valid:%v", cred.name, err)
+		}
+	}
+	if signer.signs != 1 {
+		t.Fatalf("expected the signer requested once, got %d", signer.signs)
+	}
+
+	if err := NewChainsql().AsKeystore(dir, testAddress, "wrong", 0); err != keystore.ErrPassword {
+		t.Fatalf("expected ErrPassword, got %v", err)
+	}
+
+	// the account is locked again after the timeout
+	c := NewChainsql()
+	if err := c.AsKeystore(dir, testAddress, "pass", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Pay(testUser, "1").SignOffline(&OfflineParams{Sequence: 1, Fee: 12}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, err := c.Pay(testUser, "1").SignOffline(&OfflineParams{Sequence: 1, Fee: 12}); err == nil || !strings.Contains(err.Error(), keystore.ErrLocked.Error()) {
+		t.Fatalf("expected ErrLocked after the timeout, got %v", err)
+	}
+}
+
+func TestSubmitSigned(t *testing.T) {
+	node := newFakeNode(t)
+	defer node.close()
+	blobs := make(chan interface{}, 1)
+	node.onSubmit = func(req map[string]interface{}) []interface{} {
+		blobs <- req["tx_blob"]
+		return []interface{}{response(req, map[string]interface{}{"engine_result": "tesSUCCESS"})}
+	}
+
+	offline := NewChainsql()
+	offline.As(testAddress, testSecret)
+	signed, err := offline.Pay(testUser, "1").SignOffline(&OfflineParams{Sequence: 1, Fee: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewChainsql()
+	if err := c.Connect(node.url()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	ret := c.SubmitSigned(signed.TxBlob, util.SendSuccess)
+	if ret.Status != util.SendSuccess || ret.TxHash != signed.Hash {
+		t.Fatalf("expected %s of %s, got %+v", util.SendSuccess, signed.Hash, ret)
+	}
+	if blob := <-blobs; blob != signed.TxBlob {
+		t.Fatalf("expected blob %s, got %v", signed.TxBlob, blob)
+	}
+
+	ret = c.SubmitSigned("1200", util.SendSuccess)
+	if ret.ErrorCode != "errInvalidBlob" {
+		t.Fatalf("expected errInvalidBlob, got %+v", ret)
+	}
+}
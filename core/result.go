@@ -0,0 +1,145 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+	"github.com/buger/jsonparser"
+)
+
+// AccountKeys is the account generated by GenerateAccountKeys
+type AccountKeys = crypto.Account
+
+// TableRows is the result of a table query
+type TableRows struct {
+	Lines []json.RawMessage `json:"lines"`
+}
+
+// NewTableRows parse the result of r_get or r_get_sql_user
+func NewTableRows(result string) (*TableRows, error) {
+	rows := &TableRows{}
+	err := json.Unmarshal([]byte(result), rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// String return the rows in json like the result of r_get
+func (r *TableRows) String() (string, error) {
+	rows, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return string(rows), nil
+}
+
+// Len return the count of rows
+func (r *TableRows) Len() int {
+	return len(r.Lines)
+}
+
+// Row decode the row i into v, v is usually a pointer to struct with json tags
+func (r *TableRows) Row(i int, v interface{}) error {
+	if i < 0 || i >= len(r.Lines) {
+		return fmt.Errorf("row %d out of range, total %d", i, len(r.Lines))
+	}
+	return json.Unmarshal(r.Lines[i], v)
+}
+
+// Decode decode all the rows into v, v is usually a pointer to slice of struct
+func (r *TableRows) Decode(v interface{}) error {
+	lines, err := json.Marshal(r.Lines)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(lines, v)
+}
+
+// LedgerResult is the ledger returned by GetLedgerResult
+type LedgerResult struct {
+	LedgerIndex         json.Number `json:"ledger_index"`
+	LedgerHash          string      `json:"ledger_hash"`
+	ParentHash          string      `json:"parent_hash"`
+	AccountHash         string      `json:"account_hash"`
+	TransactionHash     string      `json:"transaction_hash"`
+	CloseTime           int64       `json:"close_time"`
+	CloseTimeHuman      string      `json:"close_time_human"`
+	ParentCloseTime     int64       `json:"parent_close_time"`
+	TotalCoins          string      `json:"total_coins"`
+	Closed              bool        `json:"closed"`
+	Transactions        []string    `json:"transactions"`
+	CloseTimeResolution int         `json:"close_time_resolution"`
+	Validated           bool        `json:"-"`
+}
+
+// NewLedgerResult parse the response of ledger
+func NewLedgerResult(response string) (*LedgerResult, error) {
+	status, _ := jsonparser.GetString([]byte(response), "status")
+	if status == "error" {
+		errMsg, _ := jsonparser.GetString([]byte(response), "error_message")
+		if errMsg == "" {
+			errMsg, _ = jsonparser.GetString([]byte(response), "error")
+		}
+		return nil, errors.New(errMsg)
+	}
+	ledger, _, _, err := jsonparser.Get([]byte(response), "result", "ledger")
+	if err != nil {
+		return nil, err
+	}
+	result := &LedgerResult{}
+	err = json.Unmarshal(ledger, result)
+	if err != nil {
+		return nil, err
+	}
+	result.Validated, _ = jsonparser.GetBoolean([]byte(response), "result", "validated")
+	return result, nil
+}
+
+// ledgerResponse is the response of ledger returned by GetLedger
+type ledgerResponse struct {
+	Status string `json:"status"`
+	Result struct {
+		Ledger    *LedgerResult `json:"ledger"`
+		Validated bool          `json:"validated"`
+	} `json:"result"`
+}
+
+// String return the ledger in json like the response of ledger
+func (l *LedgerResult) String() (string, error) {
+	response := &ledgerResponse{Status: "success"}
+	response.Result.Ledger = l
+	response.Result.Validated = l.Validated
+	ledger, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(ledger), nil
+}
+
+// LedgerClosedEvent is the message of ledgerClosed stream
+type LedgerClosedEvent struct {
+	Type             string `json:"type"`
+	LedgerIndex      int    `json:"ledger_index"`
+	LedgerHash       string `json:"ledger_hash"`
+	LedgerTime       int64  `json:"ledger_time"`
+	FeeBase          int    `json:"fee_base"`
+	FeeRef           int    `json:"fee_ref"`
+	ReserveBase      int64  `json:"reserve_base"`
+	ReserveInc       int64  `json:"reserve_inc"`
+	TxnCount         int    `json:"txn_count"`
+	ValidatedLedgers string `json:"validated_ledgers"`
+}
+
+// TableEvent is the message of a subscribed table
+type TableEvent struct {
+	Type         string          `json:"type"`
+	Owner        string          `json:"owner"`
+	TableName    string          `json:"tablename"`
+	Status       string          `json:"status"`
+	Error        string          `json:"error,omitempty"`
+	ErrorMessage string          `json:"error_message,omitempty"`
+	Transaction  json.RawMessage `json:"transaction"`
+}
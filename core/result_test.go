@@ -0,0 +1,133 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableRows(t *testing.T) {
+	rows, err := NewTableRows(`{"lines":[{"id":1,"name":"echo","age":18},{"id":2,"name":"peersafe","age":10}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows.Len() != 2 {
+		t.Fatalf("expected 2 rows, got %d", rows.Len())
+	}
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	var users []user
+	if err := rows.Decode(&users); err != nil {
+		t.Fatal(err)
+	}
+	if users[1].Name != "peersafe" || users[1].Age != 10 {
+		t.Fatalf("unexpected row %+v", users[1])
+	}
+	var first user
+	if err := rows.Row(0, &first); err != nil {
+		t.Fatal(err)
+	}
+	if first.ID != 1 {
+		t.Fatalf("unexpected row %+v", first)
+	}
+	if err := rows.Row(2, &first); err == nil {
+		t.Fatal("expected out of range error")
+	}
+	str, err := rows.String()
+	if err != nil || str != `{"lines":[{"id":1,"name":"echo","age":18},{"id":2,"name":"peersafe","age":10}]}` {
+		t.Fatalf("unexpected rows %s:%v", str, err)
+	}
+}
+
+func TestLedgerResult(t *testing.T) {
+	ledger, err := NewLedgerResult(`{"id":1,"status":"success","type":"response","result":{"ledger":{"ledger_index":"20","ledger_hash":"ABCD","closed":true,"transactions":[]},"validated":true}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, err := ledger.LedgerIndex.Int64()
+	if err != nil || seq != 20 {
+		t.Fatalf("unexpected ledger_index %s", ledger.LedgerIndex)
+	}
+	if !ledger.Validated || ledger.LedgerHash != "ABCD" {
+		t.Fatalf("unexpected ledger %+v", ledger)
+	}
+
+	// the string form parses back to the same ledger
+	str, err := ledger.String()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := NewLedgerResult(str)
+	if err != nil || parsed.LedgerHash != "ABCD" || !parsed.Validated || parsed.LedgerIndex != "20" {
+		t.Fatalf("unexpected ledger %s:%v", str, err)
+	}
+
+	_, err = NewLedgerResult(`{"status":"error","error":"lgrNotFound","error_message":"ledgerNotFound"}`)
+	if err == nil || err.Error() != "ledgerNotFound" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestRequestRawResult(t *testing.T) {
+	node := newFakeNode(t)
+	defer node.close()
+	result := map[string]interface{}{
+		"lines": []map[string]interface{}{{"id": 1}},
+		"diff":  0,
+	}
+	node.onCommand = func(req map[string]interface{}) []interface{} {
+		switch req["command"] {
+		case "g_dbname":
+			return []interface{}{response(req, map[string]interface{}{"nameInDB": "A1B2C3"})}
+		case "r_get", "r_get_sql_user":
+			return []interface{}{response(req, result)}
+		}
+		return nil
+	}
+	c := newTestChainsql(t, node)
+	defer c.Disconnect()
+
+	// the string calls keep the fields other than lines
+	str, err := c.Table("t1").Get(`{"id":1}`).Request()
+	if err != nil || !strings.Contains(str, `"diff":0`) {
+		t.Fatalf("expected the raw result, got %s:%v", str, err)
+	}
+	str, err = c.GetBySqlUser("select * from t1")
+	if err != nil || !strings.Contains(str, `"diff":0`) {
+		t.Fatalf("expected the raw result, got %s:%v", str, err)
+	}
+	rows, err := c.GetBySqlUserRows("select * from t1")
+	if err != nil || rows.Len() != 1 {
+		t.Fatalf("unexpected rows %+v:%v", rows, err)
+	}
+}
+
+func TestInfoResults(t *testing.T) {
+	node := newFakeNode(t)
+	defer node.close()
+	node.onCommand = func(req map[string]interface{}) []interface{} {
+		if req["command"] == "server_info" {
+			return []interface{}{response(req, map[string]interface{}{
+				"info": map[string]interface{}{"server_state": "full", "validated_ledger": map[string]interface{}{"seq": 100}},
+			})}
+		}
+		return nil
+	}
+	c := newTestChainsql(t, node)
+	defer c.Disconnect()
+
+	str, err := c.GetServerInfo()
+	if err != nil || !strings.Contains(str, `"server_state":"full"`) {
+		t.Fatalf("expected the result of server_info, got %s:%v", str, err)
+	}
+	info, err := c.GetServerInfoResult()
+	if err != nil || info.ServerState != "full" || info.ValidatedLedger.Seq != 100 {
+		t.Fatalf("unexpected info %+v:%v", info, err)
+	}
+	str, err = c.GetAccountInfo(testAddress)
+	if err != nil || !strings.Contains(str, `"Sequence":1`) {
+		t.Fatalf("expected the result of account_info, got %s:%v", str, err)
+	}
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"context"
+	"math"
+	"time"
+
+	. "github.com/ChainSQL/go-chainsql-api/data"
+	"github.com/ChainSQL/go-chainsql-api/util"
+)
+
+// RetryPolicy controls the resubmission of a tx whose result is retriable:
+// telINSUF_FEE_P is re-signed with a higher fee, terQUEUED waits for the queue,
+// terPRE_SEQ is submitted again after a ledger closed, and tefPAST_SEQ is
+// prepared again with a resynced sequence
+type RetryPolicy struct {
+	MaxAttempts   int     // attempts including the first one
+	FeeMultiplier float64 // the fee is multiplied by it on each fee retry
+	MaxFee        int64   // the fee cap in drops, 0 for no cap
+}
+
+// NewRetryPolicy is constructor
+func NewRetryPolicy(maxAttempts int, feeMultiplier float64, maxFee int64) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:   maxAttempts,
+		FeeMultiplier: feeMultiplier,
+		MaxFee:        maxFee,
+	}
+}
+
+// TxAttempt is a submission of a tx reported in TxResult when retry is enabled
+type TxAttempt struct {
+	TxHash       string `json:"hash"`
+	Fee          int64  `json:"fee"`
+	Sequence     uint32 `json:"sequence"`
+	Status       string `json:"status"`
+	EngineResult string `json:"engineResult,omitempty"`
+}
+
+type retryAction int
+
+const (
+	retryNone retryAction = iota
+	retryFee
+	retryResubmit
+	retryPrepare
+)
+
+// SetRetryPolicy enable the resubmission of retriable results, nil to disable it,
+// the policy set on Chainsql is inherited by the Table and Ripple created from it
+func (s *SubmitBase) SetRetryPolicy(policy *RetryPolicy) {
+	s.retry = policy
+}
+
+// nextAction decide how to retry a result after attempt attempts
+func (p *RetryPolicy) nextAction(ret *TxResult, attempt int) retryAction {
+	if p == nil || attempt >= p.MaxAttempts || ret.Status != util.SendError {
+		return retryNone
+	}
+	result, ok := ParseTransactionResult(ret.engineResult)
+	if !ok {
+		return retryNone
+	}
+	switch {
+	case result.InsufficientFee():
+		return retryFee
+	case result.Retry():
+		return retryResubmit
+	case result.PastSequence():
+		return retryPrepare
+	}
+	return retryNone
+}
+
+// raiseFee multiply the fee of tx, false is returned when the cap is reached
+func (p *RetryPolicy) raiseFee(tx Transaction) bool {
+	base := tx.GetBase()
+	fee := feeDrops(base.Fee)
+	if p.FeeMultiplier <= 1 || (p.MaxFee > 0 && fee >= p.MaxFee) {
+		return false
+	}
+	newFee := int64(math.Ceil(float64(fee) * p.FeeMultiplier))
+	if p.MaxFee > 0 && newFee > p.MaxFee {
+		newFee = p.MaxFee
+	}
+	value, err := NewNativeValue(newFee)
+	if err != nil {
+		return false
+	}
+	base.Fee = *value
+	return true
+}
+
+// feeDrops return the native value in drops
+func feeDrops(v Value) int64 {
+	return int64(math.Round(v.Float() * 1000000))
+}
+
+func newTxAttempt(tx Signer, ret *TxResult) TxAttempt {
+	attempt := TxAttempt{
+		TxHash:       ret.TxHash,
+		Status:       ret.Status,
+		EngineResult: ret.engineResult,
+	}
+	if t, ok := tx.(Transaction); ok {
+		attempt.Fee = feeDrops(t.GetBase().Fee)
+		attempt.Sequence = t.GetBase().Sequence
+	}
+	return attempt
+}
+
+// waitLedgerClosed wait for the next ledger closed, or REQUEST_TIMEOUT at most
+func (s *SubmitBase) waitLedgerClosed(ctx context.Context) error {
+	closed := make(chan struct{}, 1)
+	id := s.client.Event.SubscribeLedger(func(msg string) {
+		select {
+		case closed <- struct{}{}:
+		default:
+		}
+	})
+	defer s.client.Event.UnSubscribeLedger(id)
+	select {
+	case <-closed:
+	case <-time.After(util.REQUEST_TIMEOUT * time.Second):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
@@ -1,45 +1,266 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 
+	. "github.com/ChainSQL/go-chainsql-api/data"
 	"github.com/ChainSQL/go-chainsql-api/net"
 )
 
-// Base is a struct
-type Base struct {
-}
-
-func (b *Base) Say() {
-	fmt.Println("base")
-}
-
-func (b *Base) Say2() {
-	fmt.Println("base2")
-}
-
-// Ripple is aaa
+// Ripple is used to process the ripple transactions like Payment, TrustSet and AccountSet
 type Ripple struct {
-	*Base
 	client *net.Client
+	op     *rippleOp
 	SubmitBase
 }
 
-func (r *Ripple) Say() {
-	fmt.Println("Ripple")
+// rippleOp is the details of the transaction to submit
+type rippleOp struct {
+	txType         TransactionType
+	flags          TransactionFlag
+	destination    string
+	amount         *Amount
+	sendMax        *Amount
+	deliverMin     *Amount
+	destinationTag *uint32
+	limit          *Amount
+	setFlag        *uint32
+	clearFlag      *uint32
+	transferRate   *uint32
+	memos          Memos
+	signerQuorum   uint32
+	signerEntries  []SignerEntryObj
+	err            error
 }
 
-func NewRipple() *Ripple {
+//NewRipple creates a Ripple object sharing the connection of client
+func NewRipple(client *net.Client) *Ripple {
 	ripple := &Ripple{
-		Base:   &Base{},
-		client: net.NewClient(),
+		client: client,
 	}
 	ripple.SubmitBase.client = ripple.client
 	ripple.SubmitBase.IPrepare = ripple
 	return ripple
 }
 
+// newAmountFromValue parse a decimal ZXC value like "1.5",
+// or an issued currency value like "10/USD/zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh"
+func newAmountFromValue(value string) (*Amount, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "/") {
+		value += "/ZXC"
+	}
+	return NewAmount(value)
+}
+
+func (r *Ripple) setErr(err error) {
+	if err != nil && r.op.err == nil {
+		r.op.err = err
+	}
+}
+
+//Pay pay to accountId, value is the decimal amount of ZXC like "1.5",
+//or an issued currency like "10/USD/zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh"
 func (r *Ripple) Pay(accountId string, value string) *Ripple {
+	r.op = &rippleOp{
+		txType:      PAYMENT,
+		destination: accountId,
+	}
+	amount, err := newAmountFromValue(value)
+	r.op.amount = amount
+	r.setErr(err)
+	return r
+}
+
+//PayDrops pay drops of ZXC to accountId, 1 ZXC is 1000000 drops
+func (r *Ripple) PayDrops(accountId string, drops int64) *Ripple {
+	r.op = &rippleOp{
+		txType:      PAYMENT,
+		destination: accountId,
+	}
+	amount, err := NewAmount(drops)
+	r.op.amount = amount
+	r.setErr(err)
+	return r
+}
+
+//SendMax set the maximum amount to spend in the payment, value is in the format of Pay
+func (r *Ripple) SendMax(value string) *Ripple {
+	if r.op != nil {
+		amount, err := newAmountFromValue(value)
+		r.op.sendMax = amount
+		r.setErr(err)
+	}
+	return r
+}
+
+//DeliverMin set the minimum amount to deliver in the payment, value is in the format of Pay,
+//the payment is made a partial payment
+func (r *Ripple) DeliverMin(value string) *Ripple {
+	if r.op != nil {
+		amount, err := newAmountFromValue(value)
+		r.op.deliverMin = amount
+		r.op.flags |= TxPartialPayment
+		r.setErr(err)
+	}
+	return r
+}
+
+//DestinationTag set the destination tag of the payment
+func (r *Ripple) DestinationTag(tag uint32) *Ripple {
+	if r.op != nil {
+		r.op.destinationTag = &tag
+	}
+	return r
+}
+
+//Memo add a memo to the transaction, empty fields are omitted
+func (r *Ripple) Memo(memoType string, memoData string, memoFormat string) *Ripple {
+	if r.op != nil {
+		memo := Memo{}
+		memo.Memo.MemoType = VariableLength(memoType)
+		memo.Memo.MemoData = VariableLength(memoData)
+		memo.Memo.MemoFormat = VariableLength(memoFormat)
+		r.op.memos = append(r.op.memos, memo)
+	}
+	return r
+}
+
+//TrustSet create or modify a trust line, limit is like "1000/USD/zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh"
+func (r *Ripple) TrustSet(limit string) *Ripple {
+	r.op = &rippleOp{
+		txType: TRUST_SET,
+	}
+	amount, err := NewAmount(strings.TrimSpace(limit))
+	r.op.limit = amount
+	r.setErr(err)
+	if err == nil && amount.IsNative() {
+		r.setErr(errors.New("TrustSet limit must be an issued currency"))
+	}
+	return r
+}
+
+//AuthorizeTrust authorize the trust line of holder on currency issued by the operating account,
+//it is required when RequireAuth is set by the issuer
+func (r *Ripple) AuthorizeTrust(holder string, currency string) *Ripple {
+	r.TrustSet(fmt.Sprintf("0/%s/%s", currency, holder))
+	r.op.flags |= TxSetAuth
+	return r
+}
+
+//SetDefaultRipple enable or disable rippling on the trust lines of the operating account by default
+func (r *Ripple) SetDefaultRipple(enable bool) *Ripple {
+	return r.accountSetFlag(uint32(TxDefaultRipple), enable)
+}
+
+//SetRequireAuth require or not the issuer to authorize the trust lines to the operating account
+func (r *Ripple) SetRequireAuth(enable bool) *Ripple {
+	return r.accountSetFlag(uint32(TxSetRequireAuth), enable)
+}
+
+func (r *Ripple) accountSetFlag(flag uint32, enable bool) *Ripple {
+	r.op = &rippleOp{
+		txType: ACCOUNT_SET,
+	}
+	if enable {
+		r.op.setFlag = &flag
+	} else {
+		r.op.clearFlag = &flag
+	}
+	return r
+}
 
+//SetTransferRate set the fee rate charged by the issuer when users transfer its currencies,
+//rate is a decimal string between "1.0" and "2.0" like "1.002", "0" clears the rate
+func (r *Ripple) SetTransferRate(rate string) *Ripple {
+	r.op = &rippleOp{
+		txType: ACCOUNT_SET,
+	}
+	fRate, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil {
+		r.setErr(fmt.Errorf("invalid transfer rate %s:%s", rate, err))
+		return r
+	}
+	if fRate != 0 && (fRate < 1 || fRate > 2) {
+		r.setErr(fmt.Errorf("transfer rate %s is out of range", rate))
+		return r
+	}
+	transferRate := uint32(math.Round(fRate * 1e9))
+	r.op.transferRate = &transferRate
 	return r
 }
+
+//PrepareTx prepare tx json for submit
+func (r *Ripple) PrepareTx(ctx context.Context, offline *OfflineParams) (Signer, error) {
+	return r.prepareTx(r.newTxPreparer(ctx, offline))
+}
+
+func (r *Ripple) prepareTx(p *txPreparer) (Signer, error) {
+	if r.op == nil {
+		return nil, errors.New("No transaction to submit")
+	}
+	if r.op.err != nil {
+		return nil, r.op.err
+	}
+	account, err := NewAccountFromAddress(r.client.Auth.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx Transaction
+	switch r.op.txType {
+	case PAYMENT:
+		destination, err := NewAccountFromAddress(r.op.destination)
+		if err != nil {
+			return nil, err
+		}
+		payment := &Payment{}
+		payment.Destination = *destination
+		payment.Amount = *r.op.amount
+		payment.SendMax = r.op.sendMax
+		payment.DeliverMin = r.op.deliverMin
+		payment.DestinationTag = r.op.destinationTag
+		tx = payment
+	case TRUST_SET:
+		trustSet := &TrustSet{}
+		trustSet.LimitAmount = *r.op.limit
+		tx = trustSet
+	case ACCOUNT_SET:
+		accountSet := &AccountSet{}
+		accountSet.SetFlag = r.op.setFlag
+		accountSet.ClearFlag = r.op.clearFlag
+		accountSet.TransferRate = r.op.transferRate
+		tx = accountSet
+	case SIGNER_LIST_SET:
+		tx = &SignerListSet{
+			SignerQuorum:  r.op.signerQuorum,
+			SignerEntries: r.op.signerEntries,
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported transaction type %s", r.op.txType)
+	}
+
+	seq, err := p.accountSequence()
+	if err != nil {
+		return nil, err
+	}
+	base := tx.GetBase()
+	base.TransactionType = r.op.txType
+	base.Account = *account
+	base.Sequence = seq
+	base.Memos = r.op.memos
+	if r.op.flags != 0 {
+		flags := r.op.flags
+		base.Flags = &flags
+	}
+	err = p.prepareLastLedgerAndFee(base, 0)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
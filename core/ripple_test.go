@@ -0,0 +1,147 @@
+package core
+
+import (
+	"testing"
+
+	. "github.com/ChainSQL/go-chainsql-api/data"
+)
+
+// signRipple sign r offline and decode the blob back
+func signRipple(t *testing.T, r *Ripple) Transaction {
+	t.Helper()
+	signed, err := r.SignOffline(&OfflineParams{Sequence: 1, Fee: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, _, err := parseSignedTx(signed.TxBlob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestRipplePayment(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+
+	payment, ok := signRipple(t, c.Pay(testUser, "1.5")).(*Payment)
+	if !ok || payment.Amount.String() != "1.5/ZXC" || payment.Destination.String() != testUser {
+		t.Fatalf("unexpected payment %+v", payment)
+	}
+	if payment.Flags != nil || payment.SendMax != nil || payment.DeliverMin != nil || payment.DestinationTag != nil {
+		t.Fatalf("expected the optional fields omitted, got %+v", payment)
+	}
+
+	payment = signRipple(t, c.PayDrops(testUser, 1500000)).(*Payment)
+	if payment.Amount.String() != "1.5/ZXC" {
+		t.Fatalf("expected 1500000 drops to be 1.5/ZXC, got %s", payment.Amount)
+	}
+
+	issued := "10/USD/" + testAddress
+	payment = signRipple(t, c.Pay(testUser, issued).
+		SendMax(" 12/USD/"+testAddress+" ").
+		DeliverMin("9.5/USD/"+testAddress).
+		DestinationTag(42)).(*Payment)
+	if payment.Amount.String() != issued || payment.SendMax.String() != "12/USD/"+testAddress || payment.DeliverMin.String() != "9.5/USD/"+testAddress {
+		t.Fatalf("unexpected amounts %+v", payment)
+	}
+	if payment.DestinationTag == nil || *payment.DestinationTag != 42 {
+		t.Fatalf("expected DestinationTag 42, got %v", payment.DestinationTag)
+	}
+	// DeliverMin is only valid in a partial payment
+	if payment.Flags == nil || *payment.Flags != 0x00020000 {
+		t.Fatalf("expected tfPartialPayment, got %v", payment.Flags)
+	}
+
+	payment = signRipple(t, c.Pay(testUser, "1").SendMax("2")).(*Payment)
+	if payment.Flags != nil || payment.SendMax.String() != "2/ZXC" {
+		t.Fatalf("unexpected payment %+v", payment)
+	}
+
+	for _, value := range []string{"", "abc", "1/USD/notAnAddress"} {
+		if _, err := c.Pay(testUser, value).SignOffline(&OfflineParams{Sequence: 1, Fee: 12}); err == nil {
+			t.Fatalf("expected the amount %q to fail", value)
+		}
+	}
+	if _, err := c.Pay(testUser, "1").DeliverMin("x").SignOffline(&OfflineParams{Sequence: 1, Fee: 12}); err == nil {
+		t.Fatal("expected the invalid DeliverMin to fail")
+	}
+}
+
+func TestRippleTrustSet(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+
+	limit := "1000/USD/" + testUser
+	trustSet, ok := signRipple(t, c.TrustSet(limit)).(*TrustSet)
+	if !ok || trustSet.LimitAmount.String() != limit || trustSet.Flags != nil {
+		t.Fatalf("unexpected TrustSet %+v", trustSet)
+	}
+
+	trustSet = signRipple(t, c.AuthorizeTrust(testUser, "USD")).(*TrustSet)
+	if trustSet.LimitAmount.String() != "0/USD/"+testUser {
+		t.Fatalf("expected a zero limit to %s, got %s", testUser, trustSet.LimitAmount)
+	}
+	if trustSet.Flags == nil || *trustSet.Flags != 0x00010000 {
+		t.Fatalf("expected tfSetfAuth, got %v", trustSet.Flags)
+	}
+
+	for _, limit := range []string{"1000", "1000/ZXC", "abc"} {
+		if _, err := c.TrustSet(limit).SignOffline(&OfflineParams{Sequence: 1, Fee: 12}); err == nil {
+			t.Fatalf("expected the limit %q to fail", limit)
+		}
+	}
+}
+
+func TestRippleAccountSet(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+
+	// the asf values of AccountSet
+	flags := []struct {
+		ripple *Ripple
+		set    bool
+		flag   uint32
+	}{
+		{c.SetRequireAuth(true), true, 2},
+		{c.SetRequireAuth(false), false, 2},
+		{c.SetDefaultRipple(true), true, 8},
+		{c.SetDefaultRipple(false), false, 8},
+	}
+	for i, f := range flags {
+		accountSet, ok := signRipple(t, f.ripple).(*AccountSet)
+		if !ok || accountSet.TransferRate != nil || accountSet.Flags != nil {
+			t.Fatalf("%d:unexpected AccountSet %+v", i, accountSet)
+		}
+		set, clear := accountSet.SetFlag, accountSet.ClearFlag
+		if !f.set {
+			set, clear = clear, set
+		}
+		if set == nil || *set != f.flag || clear != nil {
+			t.Fatalf("%d:expected flag %d set %v, got %+v", i, f.flag, f.set, accountSet)
+		}
+	}
+
+	rates := map[string]uint32{
+		"1":       1000000000,
+		"1.002":   1002000000,
+		"2.0":     2000000000,
+		" 1.5 ":   1500000000,
+		"0":       0,
+		"1.00001": 1000010000,
+	}
+	for rate, expected := range rates {
+		accountSet := signRipple(t, c.SetTransferRate(rate)).(*AccountSet)
+		if accountSet.TransferRate == nil || *accountSet.TransferRate != expected {
+			t.Fatalf("expected the rate %s to be %d, got %v", rate, expected, accountSet.TransferRate)
+		}
+		if accountSet.SetFlag != nil || accountSet.ClearFlag != nil {
+			t.Fatalf("unexpected flags %+v", accountSet)
+		}
+	}
+	for _, rate := range []string{"0.5", "2.1", "-1", "abc", ""} {
+		if _, err := c.SetTransferRate(rate).SignOffline(&OfflineParams{Sequence: 1, Fee: 12}); err == nil {
+			t.Fatalf("expected the rate %q to fail", rate)
+		}
+	}
+}
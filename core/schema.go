@@ -0,0 +1,242 @@
+package core
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+	. "github.com/ChainSQL/go-chainsql-api/data"
+	"github.com/ChainSQL/go-chainsql-api/util"
+)
+
+// schemaInfo is the json-object accepted by CreateSchema and ModifySchema
+type schemaInfo struct {
+	SchemaName       string
+	WithState        bool
+	SchemaAdmin      string
+	AnchorLedgerHash string
+	SchemaID         string
+	OpType           string
+	Validators       []struct {
+		Validator struct {
+			PublicKey string
+		}
+	}
+	PeerList []struct {
+		Peer struct {
+			Endpoint string
+		}
+	}
+}
+
+// schemaListParams is the json-object accepted by GetSchemaList
+type schemaListParams struct {
+	Account string `json:"account"`
+	Running *bool  `json:"running"`
+}
+
+func parseSchemaInfo(info string) (*schemaInfo, error) {
+	schema := &schemaInfo{}
+	err := json.Unmarshal([]byte(info), schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema info %s:%s", info, err)
+	}
+	return schema, nil
+}
+
+// schemaNodes convert the validator public keys, either in hex or base58 node public key
+func (s *schemaInfo) schemaNodes() ([]SchemaNode, error) {
+	nodes := make([]SchemaNode, 0, len(s.Validators))
+	for _, v := range s.Validators {
+		publicKey := v.Validator.PublicKey
+		var key []byte
+		if strings.HasPrefix(publicKey, "n") {
+			hash, err := crypto.NewRippleHashCheck(publicKey, crypto.RIPPLE_NODE_PUBLIC)
+			if err != nil {
+				return nil, err
+			}
+			key = hash.Payload()
+		} else {
+			var err error
+			key, err = hex.DecodeString(publicKey)
+			if err != nil {
+				return nil, fmt.Errorf("invalid validator public key %s:%s", publicKey, err)
+			}
+		}
+		node := SchemaNode{}
+		node.Validator.PublicKey = VariableLength(key)
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func (s *schemaInfo) schemaPeers() []SchemaPeer {
+	peers := make([]SchemaPeer, 0, len(s.PeerList))
+	for _, p := range s.PeerList {
+		peer := SchemaPeer{}
+		peer.Peer.Endpoint = VariableLength(p.Peer.Endpoint)
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
+func (s *schemaInfo) schemaModify() (*SchemaModify, error) {
+	tx := &SchemaModify{}
+	switch s.OpType {
+	case "add":
+		tx.OpType = util.SchemaAdd
+	case "del":
+		tx.OpType = util.SchemaDel
+	default:
+		return nil, fmt.Errorf("invalid schema OpType %s, should be add or del", s.OpType)
+	}
+	schemaID, err := NewHash256(s.SchemaID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SchemaID %s:%s", s.SchemaID, err)
+	}
+	tx.SchemaID = *schemaID
+	tx.Validators, err = s.schemaNodes()
+	if err != nil {
+		return nil, err
+	}
+	tx.PeerList = s.schemaPeers()
+	return tx, nil
+}
+
+//CreateSchema create a schema(sub-chain), parameter schemaInfo is a json-object string like
+// {
+//		"SchemaName":"hello",
+//		"WithState":false,
+//		"SchemaAdmin":"zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh",
+//		"AnchorLedgerHash":"",
+//		"Validators":[{"Validator":{"PublicKey":"02BD87A95F549ECF607D6AE3AEC4C95D0BFF0F49309B4E7A9F15B842EB62A8ED1B"}}],
+//		"PeerList":[{"Peer":{"Endpoint":"127.0.0.1:15125"}}]
+// }
+//AnchorLedgerHash is required when WithState is true
+func (c *Chainsql) CreateSchema(schemaInfo string) *Chainsql {
+	c.op = &tableListOp{}
+	info, err := parseSchemaInfo(schemaInfo)
+	if err != nil {
+		c.op.err = err
+		return c
+	}
+	if info.SchemaName == "" {
+		c.op.err = errors.New("SchemaName is required")
+		return c
+	}
+	tx := &SchemaCreate{}
+	tx.SchemaName = VariableLength(info.SchemaName)
+	tx.SchemaStrategy = 1
+	if info.WithState {
+		tx.SchemaStrategy = 2
+		if info.AnchorLedgerHash == "" {
+			c.op.err = errors.New("AnchorLedgerHash is required for schema with state")
+			return c
+		}
+	}
+	if info.AnchorLedgerHash != "" {
+		tx.AnchorLedgerHash, err = NewHash256(info.AnchorLedgerHash)
+		if err != nil {
+			c.op.err = fmt.Errorf("invalid AnchorLedgerHash %s:%s", info.AnchorLedgerHash, err)
+			return c
+		}
+	}
+	if info.SchemaAdmin != "" {
+		tx.SchemaAdmin, err = NewAccountFromAddress(info.SchemaAdmin)
+		if err != nil {
+			c.op.err = err
+			return c
+		}
+	}
+	tx.Validators, err = info.schemaNodes()
+	if err != nil {
+		c.op.err = err
+		return c
+	}
+	if len(tx.Validators) == 0 {
+		c.op.err = errors.New("Validators is required")
+		return c
+	}
+	tx.PeerList = info.schemaPeers()
+	tx.TransactionType = SCHEMA_CREATE
+	c.op.schema = tx
+	return c
+}
+
+//ModifySchema add or delete validators of a schema, parameter schemaInfo is a json-object string like
+// {
+//		"SchemaID":"F3A5A8B3A1F3D6E1B1F7F4B8C1D8E6C3F5E1A2B3C4D5E6F708192A3B4C5D6E7F",
+//		"OpType":"add",
+//		"Validators":[{"Validator":{"PublicKey":"02BD87A95F549ECF607D6AE3AEC4C95D0BFF0F49309B4E7A9F15B842EB62A8ED1B"}}],
+//		"PeerList":[{"Peer":{"Endpoint":"127.0.0.1:15125"}}]
+// }
+//OpType is "add" or "del"
+func (c *Chainsql) ModifySchema(schemaInfo string) *Chainsql {
+	c.op = &tableListOp{}
+	info, err := parseSchemaInfo(schemaInfo)
+	if err != nil {
+		c.op.err = err
+		return c
+	}
+	tx, err := info.schemaModify()
+	if err != nil {
+		c.op.err = err
+		return c
+	}
+	if len(tx.Validators) == 0 {
+		c.op.err = errors.New("Validators is required")
+		return c
+	}
+	tx.TransactionType = SCHEMA_MODIFY
+	c.op.schema = tx
+	return c
+}
+
+func (c *Chainsql) prepareSchemaTx(p *txPreparer) (Transaction, error) {
+	account, err := NewAccountFromAddress(c.client.Auth.Address)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := p.accountSequence()
+	if err != nil {
+		return nil, err
+	}
+	tx := c.op.schema
+	base := tx.GetBase()
+	base.Account = *account
+	base.Sequence = seq
+	err = p.prepareLastLedgerAndFee(base, 0)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+//GetSchemaList request for the schemas, parameter params is a json-object string like
+// {"account":"zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh","running":true}
+//all the schemas are returned when params is empty
+func (c *Chainsql) GetSchemaList(params string) (string, error) {
+	return c.GetSchemaListContext(context.Background(), params)
+}
+
+//GetSchemaListContext is the same as GetSchemaList but bounded by ctx
+func (c *Chainsql) GetSchemaListContext(ctx context.Context, params string) (string, error) {
+	listParams := &schemaListParams{}
+	if params != "" {
+		err := json.Unmarshal([]byte(params), listParams)
+		if err != nil {
+			return "", fmt.Errorf("invalid params %s:%s", params, err)
+		}
+	}
+	return c.client.GetSchemaListContext(ctx, listParams.Account, listParams.Running)
+}
+
+//SetSchema route the subsequent requests and transactions to the schema with id,
+//the main chain is used when id is empty
+func (c *Chainsql) SetSchema(id string) {
+	c.client.SetSchema(id)
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+	. "github.com/ChainSQL/go-chainsql-api/data"
+)
+
+const (
+	testValidator   = "02BD87A95F549ECF607D6AE3AEC4C95D0BFF0F49309B4E7A9F15B842EB62A8ED1B"
+	testSchemaID    = "F3A5A8B3A1F3D6E1B1F7F4B8C1D8E6C3F5E1A2B3C4D5E6F708192A3B4C5D6E7F"
+	testLedgerHash  = "0B1A2C3D4E5F60718293A4B5C6D7E8F90A1B2C3D4E5F60718293A4B5C6D7E8F9"
+	testSchemaPeers = `[{"Peer":{"Endpoint":"127.0.0.1:15125"}},{"Peer":{"Endpoint":"127.0.0.1:25125"}}]`
+)
+
+// signSchema sign the schema tx of c offline and decode the blob back
+func signSchema(t *testing.T, c *Chainsql) Transaction {
+	t.Helper()
+	signed, err := c.SignOffline(&OfflineParams{Sequence: 1, Fee: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, _, err := parseSignedTx(signed.TxBlob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func schemaValidators(nodes []SchemaNode) []string {
+	keys := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		keys = append(keys, strings.ToUpper(hex.EncodeToString(node.Validator.PublicKey.Bytes())))
+	}
+	return keys
+}
+
+func schemaEndpoints(peers []SchemaPeer) string {
+	endpoints := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		endpoints = append(endpoints, string(peer.Peer.Endpoint.Bytes()))
+	}
+	return strings.Join(endpoints, ",")
+}
+
+func TestCreateSchema(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+	key, _ := hex.DecodeString(testValidator)
+	nodePublic, err := crypto.NewNodePublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := fmt.Sprintf(`{"SchemaName":"hello","WithState":true,"SchemaAdmin":"%s","AnchorLedgerHash":"%s",
+		"Validators":[{"Validator":{"PublicKey":"%s"}},{"Validator":{"PublicKey":"%s"}}],"PeerList":%s}`,
+		testUser, testLedgerHash, testValidator, nodePublic.String(), testSchemaPeers)
+	schema, ok := signSchema(t, c.CreateSchema(info)).(*SchemaCreate)
+	if !ok || schema.TransactionType != SCHEMA_CREATE || schema.Account.String() != testAddress {
+		t.Fatalf("unexpected schema tx %+v", schema)
+	}
+	if string(schema.SchemaName.Bytes()) != "hello" || schema.SchemaStrategy != 2 {
+		t.Fatalf("unexpected schema %s strategy %d", schema.SchemaName.Bytes(), schema.SchemaStrategy)
+	}
+	if schema.SchemaAdmin == nil || schema.SchemaAdmin.String() != testUser {
+		t.Fatalf("unexpected SchemaAdmin %v", schema.SchemaAdmin)
+	}
+	if schema.AnchorLedgerHash == nil || schema.AnchorLedgerHash.String() != testLedgerHash {
+		t.Fatalf("unexpected AnchorLedgerHash %v", schema.AnchorLedgerHash)
+	}
+	// the base58 node public key is the same validator as the hex one
+	if keys := schemaValidators(schema.Validators); len(keys) != 2 || keys[0] != testValidator || keys[1] != testValidator {
+		t.Fatalf("unexpected validators %v", keys)
+	}
+	if endpoints := schemaEndpoints(schema.PeerList); endpoints != "127.0.0.1:15125,127.0.0.1:25125" {
+		t.Fatalf("unexpected peers %s", endpoints)
+	}
+
+	// without state
+	info = fmt.Sprintf(`{"SchemaName":"hello","Validators":[{"Validator":{"PublicKey":"%s"}}]}`, testValidator)
+	schema = signSchema(t, c.CreateSchema(info)).(*SchemaCreate)
+	if schema.SchemaStrategy != 1 || schema.SchemaAdmin != nil || schema.AnchorLedgerHash != nil || len(schema.PeerList) != 0 {
+		t.Fatalf("unexpected schema tx %+v", schema)
+	}
+
+	invalid := []string{
+		`{"SchemaName":"hello"`,
+		fmt.Sprintf(`{"Validators":[{"Validator":{"PublicKey":"%s"}}]}`, testValidator),
+		`{"SchemaName":"hello","Validators":[]}`,
+		fmt.Sprintf(`{"SchemaName":"hello","WithState":true,"Validators":[{"Validator":{"PublicKey":"%s"}}]}`, testValidator),
+		fmt.Sprintf(`{"SchemaName":"hello","AnchorLedgerHash":"abc","Validators":[{"Validator":{"PublicKey":"%s"}}]}`, testValidator),
+		fmt.Sprintf(`{"SchemaName":"hello","SchemaAdmin":"abc","Validators":[{"Validator":{"PublicKey":"%s"}}]}`, testValidator),
+		`{"SchemaName":"hello","Validators":[{"Validator":{"PublicKey":"XYZ"}}]}`,
+		`{"SchemaName":"hello","Validators":[{"Validator":{"PublicKey":"n9abc"}}]}`,
+	}
+	for _, info := range invalid {
+		if _, err := c.CreateSchema(info).SignOffline(&OfflineParams{Sequence: 1, Fee: 12}); err == nil {
+			t.Fatalf("expected %s to fail", info)
+		}
+	}
+}
+
+func TestModifySchema(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+
+	opTypes := map[string]uint16{"add": 1, "del": 2}
+	for opType, expected := range opTypes {
+		info := fmt.Sprintf(`{"SchemaID":"%s","OpType":"%s","Validators":[{"Validator":{"PublicKey":"%s"}}],"PeerList":%s}`,
+			testSchemaID, opType, testValidator, testSchemaPeers)
+		schema, ok := signSchema(t, c.ModifySchema(info)).(*SchemaModify)
+		if !ok || schema.TransactionType != SCHEMA_MODIFY || schema.OpType != expected {
+			t.Fatalf("unexpected %s schema tx %+v", opType, schema)
+		}
+		if schema.SchemaID.String() != testSchemaID {
+			t.Fatalf("unexpected SchemaID %s", schema.SchemaID)
+		}
+		if keys := schemaValidators(schema.Validators); len(keys) != 1 || keys[0] != testValidator {
+			t.Fatalf("unexpected validators %v", keys)
+		}
+		if endpoints := schemaEndpoints(schema.PeerList); endpoints != "127.0.0.1:15125,127.0.0.1:25125" {
+			t.Fatalf("unexpected peers %s", endpoints)
+		}
+	}
+
+	invalid := []string{
+		fmt.Sprintf(`{"SchemaID":"%s","OpType":"update","Validators":[{"Validator":{"PublicKey":"%s"}}]}`, testSchemaID, testValidator),
+		fmt.Sprintf(`{"SchemaID":"abc","OpType":"add","Validators":[{"Validator":{"PublicKey":"%s"}}]}`, testValidator),
+		fmt.Sprintf(`{"SchemaID":"%s","OpType":"add"}`, testSchemaID),
+		`[]`,
+	}
+	for _, info := range invalid {
+		if _, err := c.ModifySchema(info).SignOffline(&OfflineParams{Sequence: 1, Fee: 12}); err == nil {
+			t.Fatalf("expected %s to fail", info)
+		}
+	}
+}
+
+func TestGetSchemaList(t *testing.T) {
+	node := newFakeNode(t)
+	defer node.close()
+	requests := make(chan map[string]interface{}, 8)
+	node.onCommand = func(req map[string]interface{}) []interface{} {
+		if req["command"] != "schema_list" {
+			return nil
+		}
+		requests <- req
+		return []interface{}{response(req, []interface{}{map[string]interface{}{"schema_id": testSchemaID}})}
+	}
+	c := newTestChainsql(t, node)
+	defer c.Disconnect()
+
+	list, err := c.GetSchemaList(fmt.Sprintf(`{"account":"%s","running":false}`, testUser))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(list, testSchemaID) {
+		t.Fatalf("unexpected schema list %s", list)
+	}
+	req := <-requests
+	if req["account"] != testUser || req["running"] != false || req["schema_id"] != nil {
+		t.Fatalf("unexpected request %v", req)
+	}
+
+	// the empty params are omitted, and the request is routed to the schema set
+	c.SetSchema(testSchemaID)
+	if _, err := c.GetSchemaList(""); err != nil {
+		t.Fatal(err)
+	}
+	req = <-requests
+	if _, ok := req["account"]; ok {
+		t.Fatalf("expected no account, got %v", req)
+	}
+	if _, ok := req["running"]; ok {
+		t.Fatalf("expected no running, got %v", req)
+	}
+	if req["schema_id"] != testSchemaID {
+		t.Fatalf("expected the schema_id stamped, got %v", req)
+	}
+
+	c.SetSchema("")
+	if _, err := c.GetSchemaList(`{"running":true}`); err != nil {
+		t.Fatal(err)
+	}
+	req = <-requests
+	if req["running"] != true || req["schema_id"] != nil {
+		t.Fatalf("unexpected request %v", req)
+	}
+
+	if _, err := c.GetSchemaList(`{"running":"yes"}`); err == nil {
+		t.Fatal("expected the invalid params to fail")
+	}
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -19,75 +20,177 @@ import (
 type TxJSON interface {
 }
 
-// TxSigned is signed transaction
+// TxSigned is signed transaction, txJSON is set for a multi-signed one
 type TxSigned struct {
-	blob string
-	hash string
+	blob               string
+	hash               string
+	lastLedgerSequence uint32
+	txJSON             interface{}
 }
 
 // TxResult is tx submit response
 type TxResult struct {
-	Status       string `json:"status"`
-	TxHash       string `json:"hash"`
-	ErrorCode    string `json:"error,omitempty"`
-	ErrorMessage string `json:"errorMessage,omitempty"`
+	Status       string      `json:"status"`
+	TxHash       string      `json:"hash"`
+	ErrorCode    string      `json:"error,omitempty"`
+	ErrorMessage string      `json:"errorMessage,omitempty"`
+	Attempts     []TxAttempt `json:"attempts,omitempty"`
+	engineResult string
 }
 
-// IPrepare is an interface that a struct call submit() method must implment
+// IPrepare is an interface that a struct call submit() method must implment,
+// the fields of the tx are requested from the node or taken offline by p
 type IPrepare interface {
-	PrepareTx() (Signer, error)
+	prepareTx(p *txPreparer) (Signer, error)
 }
 
-// SubmitBase base struct
+// SubmitBase base struct, the state of a submit is passed down
+// instead of kept in it, so it can be submitted concurrently
 type SubmitBase struct {
-	expect   string
-	callback export.Callback
-	client   *net.Client
+	client *net.Client
+	retry  *RetryPolicy
+	tokens *tableTokens
 	IPrepare
 }
 
-// Submit submit a tx with a cocurrent expect
+// Submit submit a tx with a cocurrent expect and return the TxResult in json format
 func (s *SubmitBase) Submit(cond string) string {
-	s.expect = cond
-	ret := s.doSubmit()
+	ret := s.SubmitResult(cond)
 	jsonRet, _ := json.Marshal(ret)
 	return string(jsonRet)
 }
 
-//SubmitAsync submit a transaction and got response asynchronously
+// SubmitResult submit a tx with a cocurrent expect
+func (s *SubmitBase) SubmitResult(cond string) *TxResult {
+	ret, _ := s.SubmitResultContext(context.Background(), cond)
+	return ret
+}
+
+// SubmitContext is the same as Submit but stop waiting when ctx is done,
+// the tx subscription is cleaned up and ctx.Err() returned in that case
+func (s *SubmitBase) SubmitContext(ctx context.Context, cond string) (string, error) {
+	ret, err := s.SubmitResultContext(ctx, cond)
+	jsonRet, _ := json.Marshal(ret)
+	return string(jsonRet), err
+}
+
+// SubmitResultContext is the same as SubmitResult but bounded by ctx,
+// the TxResult returned with ctx.Err() still carries the hash if the tx was signed
+func (s *SubmitBase) SubmitResultContext(ctx context.Context, cond string) (*TxResult, error) {
+	return s.doSubmit(ctx, cond)
+}
+
+//SubmitAsync submit a transaction and got response asynchronously,
+//callback is triggered with the result of db_success in json format,
+//or validate_success for non-chainsql transactions like Payment
 func (s *SubmitBase) SubmitAsync(callback export.Callback) {
-	s.callback = callback
-	s.doSubmit()
+	s.SubmitAsyncResult(func(ret *TxResult) {
+		jsonRet, _ := json.Marshal(ret)
+		callback(string(jsonRet))
+	})
 }
 
-func (s *SubmitBase) doSubmit() *TxResult {
-	tx, err := s.PrepareTx()
+//SubmitAsyncResult is the same as SubmitAsync but got the TxResult in callback
+func (s *SubmitBase) SubmitAsyncResult(callback func(*TxResult)) {
+	go func() {
+		ret, _ := s.doSubmit(context.Background(), util.DbSuccess)
+		callback(ret)
+	}()
+}
+
+// expectFor return the status to wait for tx when expect is asked,
+// db_success is only for chainsql transactions, and not polled over http
+func (s *SubmitBase) expectFor(expect string, tx Signer) string {
+	if expect == util.DbSuccess && (!util.IsChainsqlType(tx.GetType()) || !s.client.Streaming()) {
+		return util.ValidateSuccess
+	}
+	return expect
+}
+
+func (s *SubmitBase) doSubmit(ctx context.Context, expect string) (*TxResult, error) {
+	tx, err := s.prepareTx(s.newTxPreparer(ctx, nil))
 	if err != nil {
+		// the sequence may have been handed out before the failure
+		s.client.Sequences.Reset(s.client.Auth.Address)
 		log.Printf("doSubmit error:%s\n", err)
 		return &TxResult{
 			ErrorCode:    "errPrepareTx",
 			ErrorMessage: err.Error(),
-		}
+		}, ctx.Err()
 	}
+	expect = s.expectFor(expect, tx)
 	// str, err := json.Marshal(tx)
 	// if err != nil {
 	// 	log.Println(err)
 	// }
 	// log.Println(string(str))
 
-	key, err := KeyFromSecret(s.client.Auth.Secret)
+	var attempts []TxAttempt
+	var txSigned *TxSigned
+	for attempt := 1; ; attempt++ {
+		if txSigned == nil {
+			var ret *TxResult
+			txSigned, ret = s.signTx(tx)
+			if ret != nil {
+				s.settleSequence(tx, ret)
+				ret.Attempts = attempts
+				return ret, nil
+			}
+		}
+
+		ret, err := s.handleSignedTx(ctx, txSigned, expect)
+		if s.retry != nil {
+			attempts = append(attempts, newTxAttempt(tx, ret))
+			ret.Attempts = attempts
+		}
+		if err != nil {
+			s.settleSequence(tx, ret)
+			return ret, err
+		}
+
+		switch s.retry.nextAction(ret, attempt) {
+		case retryFee:
+			if t, ok := tx.(Transaction); ok && s.retry.raiseFee(t) {
+				txSigned = nil
+				continue
+			}
+		case retryResubmit:
+			if err := s.waitLedgerClosed(ctx); err != nil {
+				s.settleSequence(tx, ret)
+				return ret, err
+			}
+			continue
+		case retryPrepare:
+			s.settleSequence(tx, ret)
+			newTx, err := s.prepareTx(s.newTxPreparer(ctx, nil))
+			if err != nil {
+				s.client.Sequences.Reset(s.client.Auth.Address)
+				log.Printf("doSubmit error:%s\n", err)
+				return ret, ctx.Err()
+			}
+			tx = newTx
+			txSigned = nil
+			continue
+		}
+		s.settleSequence(tx, ret)
+		return ret, nil
+	}
+}
+
+// signTx sign and serialize tx, the TxResult is returned on failure
+func (s *SubmitBase) signTx(tx Signer) (*TxSigned, *TxResult) {
+	signer, err := s.client.Auth.KeySigner()
 	if err != nil {
 		log.Printf("doSubmit error:%s\n", err)
-		return &TxResult{
+		return nil, &TxResult{
 			ErrorCode:    "errGenerateKey",
 			ErrorMessage: err.Error(),
 		}
 	}
-	sequenceZero := uint32(0)
-	err = Sign(tx, key, &sequenceZero)
+	err = SignWith(tx, signer)
 	if err != nil {
 		log.Printf("doSubmit error:%s\n", err)
-		return &TxResult{
+		return nil, &TxResult{
 			ErrorCode:    "errSign",
 			ErrorMessage: err.Error(),
 		}
@@ -96,7 +199,7 @@ func (s *SubmitBase) doSubmit() *TxResult {
 	_, blob, err := Raw(tx)
 	if err != nil {
 		log.Printf("doSubmit error:%s\n", err)
-		return &TxResult{
+		return nil, &TxResult{
 			ErrorCode:    "errSerialize",
 			ErrorMessage: err.Error(),
 		}
@@ -105,11 +208,46 @@ func (s *SubmitBase) doSubmit() *TxResult {
 		blob: fmt.Sprintf("%X", blob),
 		hash: string(crypto.B2H32(*tx.GetHash())),
 	}
+	if t, ok := tx.(Transaction); ok && t.GetBase().LastLedgerSequence != nil {
+		txSigned.lastLedgerSequence = *t.GetBase().LastLedgerSequence
+	}
 
 	// log.Printf("hash:%s\n", txSigned.hash)
 	// log.Printf("blob:%s\n", txSigned.blob)
+	return txSigned, nil
+}
 
-	return s.handleSignedTx(txSigned)
+// settleSequence gives the sequence of tx back to client.Sequences when the tx
+// is not applied, or resyncs it when the result says the local one is wrong
+func (s *SubmitBase) settleSequence(tx Signer, ret *TxResult) {
+	t, ok := tx.(Transaction)
+	if !ok {
+		return
+	}
+	base := t.GetBase()
+	address := base.Account.String()
+	switch ret.Status {
+	case util.ValidateTimeout:
+		s.client.Sequences.Reset(address)
+	case util.SendError:
+		result, ok := ParseTransactionResult(ret.ErrorCode)
+		switch {
+		case !ok:
+			// rejected by rpc before applying
+			s.client.Sequences.Release(address, base.Sequence)
+		case result.SequenceMismatch():
+			s.client.Sequences.Reset(address)
+		case !result.Success() && !result.Claimed() && !result.Queued():
+			s.client.Sequences.Release(address, base.Sequence)
+		}
+	case "":
+		if ret.ErrorCode == "errContext" {
+			// unknown whether the tx has been applied
+			s.client.Sequences.Reset(address)
+		} else {
+			s.client.Sequences.Release(address, base.Sequence)
+		}
+	}
 }
 
 func (s *SubmitBase) checkWaitGroupDone(wait *sync.WaitGroup, countDone *int, maxDone int) bool {
@@ -122,67 +260,129 @@ func (s *SubmitBase) checkWaitGroupDone(wait *sync.WaitGroup, countDone *int, ma
 	}
 }
 
-// handleSignedTx handles signed transaction submit
+// handleSignedTx handles signed transaction submit and wait for the expect status
 // Chainsql will re-use this function when commit called
-func (s *SubmitBase) handleSignedTx(tx *TxSigned) *TxResult {
+func (s *SubmitBase) handleSignedTx(ctx context.Context, tx *TxSigned, expect string) (*TxResult, error) {
 	ret := &TxResult{}
 	wait := new(sync.WaitGroup)
+	mutex := new(sync.Mutex)
 	countDone := 0
 	maxDone := 0
-	if s.expect != util.SendSuccess {
+	// validated and dbSynced are tracked apart from countDone to tell
+	// which step is missing when LastLedgerSequence passes
+	validated := false
+	dbSynced := false
+	timeout := ""
+	switch expect {
+	case util.ValidateSuccess:
+		maxDone = 1
+	case util.DbSuccess:
+		maxDone = 2
+	}
+	wait.Add(maxDone)
+	// releaseAll is called with mutex locked
+	releaseAll := func() {
+		for s.checkWaitGroupDone(wait, &countDone, maxDone) {
+		}
+	}
+	if expect != util.SendSuccess {
 		// subscribe for result
 		s.client.SubscribeTx(tx.hash, func(msg string) {
 			// log.Println(msg)
-			if !s.checkWaitGroupDone(wait, &countDone, maxDone) {
-				// log.Printf("Already %d times of wait.Done,msg=%s \n", countDone, msg)
-				return
-			}
 			status, err := jsonparser.GetString([]byte(msg), "status")
 			if err != nil {
 				log.Printf("handleSignedTx error:%s\n", err)
 				return
 			}
 
+			mutex.Lock()
+			defer mutex.Unlock()
+			if countDone >= maxDone {
+				// log.Printf("Already %d times of wait.Done,msg=%s \n", countDone, msg)
+				return
+			}
 			ret.Status = status
 			ret.TxHash = tx.hash
+			switch {
+			case status == util.ValidateSuccess:
+				validated = true
+			case status == util.DbSuccess:
+				validated = true
+				dbSynced = true
+			}
+			if status == util.ValidateError {
+				errCode, _ := jsonparser.GetString([]byte(msg), "error")
+				errorMessage, _ := jsonparser.GetString([]byte(msg), "error_message")
+				ret.ErrorCode = errCode
+				ret.ErrorMessage = errorMessage
+			}
 			doneTwice := false
 			//db_success come before validate_success
 			// validate_success will not come any more
-			if status == s.expect && status == util.DbSuccess && countDone == 1 {
+			if status == expect && status == util.DbSuccess && countDone == 0 {
 				doneTwice = true
 			}
-			if status != s.expect {
-				if status != util.ValidateSuccess && status != util.DbSuccess {
-					if status == util.ValidateError {
-						errCode, _ := jsonparser.GetString([]byte(msg), "error")
-						errorMessage, _ := jsonparser.GetString([]byte(msg), "error_message")
-						ret.ErrorCode = errCode
-						ret.ErrorMessage = errorMessage
-					}
-					if s.expect == util.DbSuccess &&
-						(strings.Contains(status, "validate_") ||
-							(strings.Contains(status, "db_") && countDone == 1)) {
-						doneTwice = true
-					}
+			if status != expect && status != util.ValidateSuccess && status != util.DbSuccess {
+				if expect == util.DbSuccess &&
+					(strings.Contains(status, "validate_") ||
+						(strings.Contains(status, "db_") && countDone == 0)) {
+					doneTwice = true
 				}
 			}
+			s.checkWaitGroupDone(wait, &countDone, maxDone)
 			if doneTwice {
-				if !s.checkWaitGroupDone(wait, &countDone, maxDone) {
-					// log.Printf("Already %d times of wait.Done,msg=%s \n", countDone, msg)
-					return
-				}
+				s.checkWaitGroupDone(wait, &countDone, maxDone)
 			}
 		})
+
+		// the tx will never be validated once the validated ledger passes its LastLedgerSequence
+		if tx.lastLedgerSequence != 0 {
+			ledgerSub := s.client.Event.SubscribeLedger(func(msg string) {
+				ledgerIndex, err := jsonparser.GetInt([]byte(msg), "ledger_index")
+				if err != nil || ledgerIndex <= int64(tx.lastLedgerSequence) {
+					return
+				}
+				mutex.Lock()
+				defer mutex.Unlock()
+				if countDone >= maxDone {
+					return
+				}
+				// the last status received is returned if it is a final one
+				switch {
+				case !validated:
+					timeout = util.ValidateTimeout
+				case expect == util.DbSuccess && !dbSynced:
+					timeout = util.DbTimeout
+				}
+				releaseAll()
+			})
+			defer s.client.Event.UnSubscribeLedger(ledgerSub)
+		}
 	}
 
 	//submit transaction
-	response := s.client.Submit(tx.blob)
+	var response string
+	var err error
+	if tx.txJSON != nil {
+		response, err = s.client.SubmitMultiSignedContext(ctx, tx.txJSON)
+	} else {
+		response, err = s.client.SubmitContext(ctx, tx.blob)
+	}
+	if err != nil {
+		if expect != util.SendSuccess {
+			s.client.UnSubscribeTx(tx.hash)
+		}
+		return contextResult(tx, err), err
+	}
 	status, err := jsonparser.GetString([]byte(response), "status")
 	if err != nil {
 		log.Printf("handleSignedTx error:%s\n", err)
 	}
 	if status == "error" {
 		log.Printf("Send tx error:%s\n", response)
+		if expect != util.SendSuccess {
+			s.client.UnSubscribeTx(tx.hash)
+		}
 		errorCode, _ := jsonparser.GetString([]byte(response), "error")
 		errorMessage, _ := jsonparser.GetString([]byte(response), "error_message")
 		return &TxResult{
@@ -190,32 +390,59 @@ func (s *SubmitBase) handleSignedTx(tx *TxSigned) *TxResult {
 			TxHash:       tx.hash,
 			ErrorCode:    errorCode,
 			ErrorMessage: errorMessage,
-		}
+		}, nil
 	}
 	result, err := jsonparser.GetString([]byte(response), "result", "engine_result")
 	if err != nil {
 		log.Printf("handleSignedTx error:%s\n", err)
 	}
-	if result == "tesSUCCESS" {
-		if s.expect == util.SendSuccess {
+	// a queued tx is waited for like an applied one when retry is enabled
+	if result == "tesSUCCESS" || (s.retry != nil && result == "terQUEUED") {
+		if expect == util.SendSuccess {
 			return &TxResult{
-				Status: util.SendSuccess,
-				TxHash: tx.hash,
-			}
+				Status:       util.SendSuccess,
+				TxHash:       tx.hash,
+				engineResult: result,
+			}, nil
 		} else {
 			//waiting for subscribe result
-			if s.expect == util.ValidateSuccess {
-				wait.Add(1)
-				maxDone = 1
-			} else {
-				wait.Add(2)
-				maxDone = 2
+			waitDone := make(chan struct{})
+			go func() {
+				wait.Wait()
+				close(waitDone)
+			}()
+			select {
+			case <-waitDone:
+				s.client.UnSubscribeTx(tx.hash)
+			case <-ctx.Done():
+				s.client.UnSubscribeTx(tx.hash)
+				// release the waiter goroutine
+				mutex.Lock()
+				releaseAll()
+				mutex.Unlock()
+				return contextResult(tx, ctx.Err()), ctx.Err()
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch timeout {
+			case util.ValidateTimeout:
+				return &TxResult{
+					Status:       util.ValidateTimeout,
+					TxHash:       tx.hash,
+					engineResult: result,
+					ErrorMessage: fmt.Sprintf("LastLedgerSequence %d passed without a result", tx.lastLedgerSequence),
+				}, nil
+			case util.DbTimeout:
+				return &TxResult{
+					Status:       util.DbTimeout,
+					TxHash:       tx.hash,
+					engineResult: result,
+					ErrorMessage: fmt.Sprintf("validated but not synced to the database before LastLedgerSequence %d passed", tx.lastLedgerSequence),
+				}, nil
 			}
-			wait.Wait()
-			s.client.UnSubscribeTx(tx.hash)
 		}
 	} else {
-		if s.expect != util.SendSuccess {
+		if expect != util.SendSuccess {
 			s.client.UnSubscribeTx(tx.hash)
 		}
 
@@ -226,8 +453,48 @@ func (s *SubmitBase) handleSignedTx(tx *TxSigned) *TxResult {
 			TxHash:       tx.hash,
 			ErrorCode:    err,
 			ErrorMessage: errorMessage,
+			engineResult: err,
+		}, nil
+	}
+
+	ret.engineResult = result
+	return ret, nil
+}
+
+// contextResult is the TxResult returned when ctx is done before the expected status
+func contextResult(tx *TxSigned, err error) *TxResult {
+	return &TxResult{
+		TxHash:       tx.hash,
+		ErrorCode:    "errContext",
+		ErrorMessage: err.Error(),
+	}
+}
+
+// prepareLastLedger fills the LastLedgerSequence of a tx and return the basic fee in drops
+func prepareLastLedger(ctx context.Context, client *net.Client, tx *TxBase) (int64, error) {
+	var fee int64 = 10
+	if info := client.ServerInfo.Snapshot(); info.Updated {
+		last := uint32(info.LedgerIndex + 20)
+		tx.LastLedgerSequence = &last
+		fee = int64(info.ComputeFee())
+	} else {
+		ledgerIndex, err := client.GetLedgerVersionContext(ctx)
+		if err != nil {
+			return 0, err
 		}
+		last := uint32(ledgerIndex + 20)
+		tx.LastLedgerSequence = &last
+
+		fee = 50
 	}
+	return fee, nil
+}
 
-	return ret
+// getLedgerIndex return the cached ledger index, or request for it when
+// the ServerInfo has not been updated
+func getLedgerIndex(ctx context.Context, client *net.Client) (int, error) {
+	if info := client.ServerInfo.Snapshot(); info.Updated {
+		return info.LedgerIndex, nil
+	}
+	return client.GetLedgerVersionContext(ctx)
 }
@@ -0,0 +1,333 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ChainSQL/go-chainsql-api/util"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	testAddress = "zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh"
+	testSecret  = "xnoPBzXtMeMyMHUVTgbuqAfg1SUTb"
+	testUser    = "zBonp9s7isAaDUPcfrFfYjNnhgeznoBHxF"
+)
+
+// fakeNode is a websocket server answering the commands used by submit,
+// onSubmit is called with the submit request and returns the messages to send back,
+// onCommand is called the same with the requests of the other commands
+type fakeNode struct {
+	server    *httptest.Server
+	mutex     sync.Mutex
+	conns     []*websocket.Conn
+	onSubmit  func(req map[string]interface{}) []interface{}
+	onCommand func(req map[string]interface{}) []interface{}
+}
+
+func newFakeNode(t *testing.T) *fakeNode {
+	node := &fakeNode{}
+	upgrader := websocket.Upgrader{}
+	node.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade error:%s", err)
+			return
+		}
+		node.mutex.Lock()
+		node.conns = append(node.conns, conn)
+		node.mutex.Unlock()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req map[string]interface{}
+			if json.Unmarshal(msg, &req) != nil {
+				continue
+			}
+			for _, reply := range node.handle(req) {
+				node.mutex.Lock()
+				conn.WriteJSON(reply)
+				node.mutex.Unlock()
+			}
+		}
+	}))
+	return node
+}
+
+func (node *fakeNode) url() string {
+	return "ws" + strings.TrimPrefix(node.server.URL, "http")
+}
+
+func (node *fakeNode) close() {
+	node.mutex.Lock()
+	for _, conn := range node.conns {
+		conn.Close()
+	}
+	node.mutex.Unlock()
+	node.server.Close()
+}
+
+func response(req map[string]interface{}, result interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":     req["id"],
+		"type":   "response",
+		"status": "success",
+		"result": result,
+	}
+}
+
+func (node *fakeNode) handle(req map[string]interface{}) []interface{} {
+	switch req["command"] {
+	case "subscribe", "unsubscribe":
+		if req["id"] == nil {
+			return nil
+		}
+		return []interface{}{response(req, map[string]interface{}{
+			"ledger_index": 100,
+			"fee_base":     10,
+			"fee_ref":      10,
+		})}
+	case "account_info":
+		return []interface{}{response(req, map[string]interface{}{
+			"account_data": map[string]interface{}{
+				"Account":  req["account"],
+				"Balance":  "1000000000",
+				"Sequence": 1,
+			},
+			"ledger_current_index": 100,
+		})}
+	case "submit", "submit_multisigned":
+		return node.onSubmit(req)
+	}
+	if node.onCommand != nil {
+		return node.onCommand(req)
+	}
+	return nil
+}
+
+func newTestChainsql(t *testing.T, node *fakeNode) *Chainsql {
+	c := NewChainsql()
+	if err := c.Connect(node.url()); err != nil {
+		t.Fatal(err)
+	}
+	c.As(testAddress, testSecret)
+	return c
+}
+
+func TestSubmitValidateTimeout(t *testing.T) {
+	node := newFakeNode(t)
+	defer node.close()
+	node.onSubmit = func(req map[string]interface{}) []interface{} {
+		// accepted but never validated, LastLedgerSequence is 120
+		return []interface{}{
+			response(req, map[string]interface{}{"engine_result": "tesSUCCESS"}),
+			map[string]interface{}{"type": "ledgerClosed", "ledger_index": 120},
+			map[string]interface{}{"type": "ledgerClosed", "ledger_index": 121},
+		}
+	}
+	c := newTestChainsql(t, node)
+	defer c.Disconnect()
+
+	ret := c.Pay(testUser, "1").SubmitResult(util.ValidateSuccess)
+	if ret.Status != util.ValidateTimeout {
+		t.Fatalf("expected %s, got %+v", util.ValidateTimeout, ret)
+	}
+	if len(ret.TxHash) != 64 {
+		t.Fatalf("expected the tx hash, got %q", ret.TxHash)
+	}
+}
+
+func TestSubmitDbTimeout(t *testing.T) {
+	node := newFakeNode(t)
+	defer node.close()
+	node.onCommand = func(req map[string]interface{}) []interface{} {
+		switch req["command"] {
+		case "g_dbname":
+			return []interface{}{response(req, map[string]interface{}{"nameInDB": "A1B2C3"})}
+		}
+		return nil
+	}
+	node.onSubmit = func(req map[string]interface{}) []interface{} {
+		_, txSigned, _ := decodeTx(req["tx_blob"].(string))
+		// validated but db_success never comes, LastLedgerSequence is 120
+		go func() {
+			// the messages are dispatched concurrently, the ledger comes after the result
+			time.Sleep(100 * time.Millisecond)
+			node.mutex.Lock()
+			defer node.mutex.Unlock()
+			for _, conn := range node.conns {
+				conn.WriteJSON(map[string]interface{}{"type": "ledgerClosed", "ledger_index": 121})
+			}
+		}()
+		return []interface{}{
+			response(req, map[string]interface{}{"engine_result": "tesSUCCESS"}),
+			map[string]interface{}{
+				"type":        "singleTransaction",
+				"status":      util.ValidateSuccess,
+				"transaction": map[string]interface{}{"hash": txSigned.hash},
+			},
+		}
+	}
+	c := newTestChainsql(t, node)
+	defer c.Disconnect()
+
+	ret := c.Table("t1").Insert(`[{"id":1}]`).SubmitResult(util.DbSuccess)
+	if ret.Status != util.DbTimeout || len(ret.TxHash) != 64 {
+		t.Fatalf("expected %s, got %+v", util.DbTimeout, ret)
+	}
+
+	// only validation is waited for
+	ret = c.Table("t1").Insert(`[{"id":1}]`).SubmitResult(util.ValidateSuccess)
+	if ret.Status != util.ValidateSuccess {
+		t.Fatalf("expected %s, got %+v", util.ValidateSuccess, ret)
+	}
+}
+
+func TestSubmitRetryFee(t *testing.T) {
+	node := newFakeNode(t)
+	defer node.close()
+	var submits int32
+	node.onSubmit = func(req map[string]interface{}) []interface{} {
+		result := "tesSUCCESS"
+		if atomic.AddInt32(&submits, 1) == 1 {
+			result = "telINSUF_FEE_P"
+		}
+		return []interface{}{response(req, map[string]interface{}{"engine_result": result})}
+	}
+	c := newTestChainsql(t, node)
+	defer c.Disconnect()
+	c.SetRetryPolicy(NewRetryPolicy(3, 2, 0))
+
+	ret := c.Pay(testUser, "1").SubmitResult(util.SendSuccess)
+	if ret.Status != util.SendSuccess || len(ret.Attempts) != 2 {
+		t.Fatalf("expected %s after 2 attempts, got %+v", util.SendSuccess, ret)
+	}
+	first, second := ret.Attempts[0], ret.Attempts[1]
+	if first.EngineResult != "telINSUF_FEE_P" || second.Fee != first.Fee*2 || second.Sequence != first.Sequence {
+		t.Fatalf("unexpected attempts %+v", ret.Attempts)
+	}
+
+	// the fee cap has been reached
+	atomic.StoreInt32(&submits, 0)
+	c.SetRetryPolicy(NewRetryPolicy(3, 2, first.Fee))
+	ret = c.Pay(testUser, "1").SubmitResult(util.SendSuccess)
+	if ret.Status != util.SendError || ret.ErrorCode != "telINSUF_FEE_P" || len(ret.Attempts) != 1 {
+		t.Fatalf("expected telINSUF_FEE_P after 1 attempt, got %+v", ret)
+	}
+}
+
+func TestConnectFailover(t *testing.T) {
+	node1 := newFakeNode(t)
+	node2 := newFakeNode(t)
+	defer node2.close()
+	c := NewChainsql()
+	if err := c.Connect(node1.url(), node2.url()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	c.As(testAddress, testSecret)
+
+	nodes := c.Nodes()
+	if len(nodes) != 2 || !nodes[0].Active {
+		t.Fatalf("expected the first node active, got %+v", nodes)
+	}
+
+	node1.close()
+	for i := 0; i < 100 && c.Nodes()[0].Connected; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	info, err := c.GetAccountInfoResult(testAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Sequence != 1 {
+		t.Fatalf("unexpected account info %+v", info)
+	}
+	nodes = c.Nodes()
+	if !nodes[1].Active {
+		t.Fatalf("expected the second node active, got %+v", nodes)
+	}
+}
+
+// newFakeRPCNode is a JSON-RPC server answering the commands used by submit,
+// the submitted txs are validated with tesSUCCESS
+func newFakeRPCNode(t *testing.T) *httptest.Server {
+	var mutex sync.Mutex
+	submitted := make(map[string]bool)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string                   `json:"method"`
+			Params []map[string]interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Params) != 1 {
+			t.Errorf("invalid request %v", err)
+			return
+		}
+		params := req.Params[0]
+		result := map[string]interface{}{"status": "success"}
+		switch req.Method {
+		case "server_state":
+			result["state"] = map[string]interface{}{
+				"server_state": "full",
+				"load_base":    256,
+				"load_factor":  256,
+				"validated_ledger": map[string]interface{}{
+					"seq":      100,
+					"base_fee": 10,
+				},
+			}
+		case "account_info":
+			result["account_data"] = map[string]interface{}{
+				"Account":  params["account"],
+				"Balance":  "1000000000",
+				"Sequence": 1,
+			}
+		case "submit":
+			result["engine_result"] = "tesSUCCESS"
+			mutex.Lock()
+			submitted[params["tx_blob"].(string)] = true
+			mutex.Unlock()
+		case "tx":
+			mutex.Lock()
+			result["validated"] = len(submitted) > 0
+			mutex.Unlock()
+			result["TransactionType"] = "Payment"
+			result["meta"] = map[string]interface{}{"TransactionResult": "tesSUCCESS"}
+		default:
+			result = map[string]interface{}{"status": "error", "error": "unknownCmd", "error_message": "Unknown method."}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+}
+
+func TestSubmitOverHTTP(t *testing.T) {
+	server := newFakeRPCNode(t)
+	defer server.Close()
+	c := NewChainsql()
+	if err := c.Connect(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	c.As(testAddress, testSecret)
+
+	info, err := c.GetAccountInfoResult(testAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Sequence != 1 {
+		t.Fatalf("unexpected account info %+v", info)
+	}
+
+	ret := c.Pay(testUser, "1").SubmitResult(util.ValidateSuccess)
+	if ret.Status != util.ValidateSuccess || len(ret.TxHash) != 64 {
+		t.Fatalf("expected %s, got %+v", util.ValidateSuccess, ret)
+	}
+}
@@ -1,8 +1,10 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 
 	. "github.com/ChainSQL/go-chainsql-api/data"
@@ -15,6 +17,7 @@ type OpInfo struct {
 	Raw   string
 	Exec  uint16
 	Query []interface{}
+	err   error
 }
 
 type TableGetJSON struct {
@@ -30,6 +33,11 @@ type Table struct {
 	name   string
 	client *net.Client
 	op     *OpInfo
+	// chainsql is the Chainsql the table is got from, the operations join
+	// the transaction begun on it when they are created
+	chainsql *Chainsql
+	// inTran is set when the current operation is put into a transaction
+	inTran bool
 	SubmitBase
 }
 
@@ -43,6 +51,7 @@ func NewTable(name string, client *net.Client) *Table {
 		},
 	}
 	table.SubmitBase.client = table.client
+	table.SubmitBase.tokens = newTableTokens()
 	table.SubmitBase.IPrepare = table
 	return table
 }
@@ -51,9 +60,68 @@ func NewTable(name string, client *net.Client) *Table {
 func (t *Table) Insert(value string) *Table {
 	t.op.Exec = util.RInsert
 	t.op.Raw = value
+	t.addToTran()
 	return t
 }
 
+//Update method update the records matching where with the values in set
+//parameter set is a json-object string like {"age":20}, it must not be empty
+//parameter where is a json-object string the same as Get, like {"id":1},
+//all the records are updated if where is empty
+func (t *Table) Update(set string, where string) *Table {
+	t.op.Exec = util.RUpdate
+	if set == "" {
+		t.op.err = errors.New("nothing to set in update")
+	} else {
+		t.op.Raw, t.op.err = formatOpRaw(set, where)
+	}
+	t.addToTran()
+	return t
+}
+
+//Delete method delete the records matching where
+//parameter where is a json-object string the same as Get, like {"id":1}
+func (t *Table) Delete(where string) *Table {
+	t.op.Exec = util.RDelete
+	t.op.Raw, t.op.err = formatOpRaw(where)
+	t.addToTran()
+	return t
+}
+
+// addToTran put the current operation into the transaction begun by Chainsql.BeginTran
+func (t *Table) addToTran() {
+	t.inTran = false
+	if t.chainsql == nil || t.chainsql.tran == nil {
+		return
+	}
+	t.chainsql.tran.add(t.name, t.client.Auth.Owner, *t.op)
+	t.inTran = true
+}
+
+// formatOpRaw joins the json-object conditions into the json-array Raw
+// expected by update and delete, an empty condition is put as {}
+// to keep the position of each condition
+func formatOpRaw(conds ...string) (string, error) {
+	raw := make([]interface{}, 0, len(conds))
+	for _, cond := range conds {
+		if cond == "" {
+			raw = append(raw, map[string]interface{}{})
+			continue
+		}
+		var jsonObj interface{}
+		err := json.Unmarshal([]byte(cond), &jsonObj)
+		if err != nil {
+			return "", fmt.Errorf("invalid condition %s:%s", cond, err)
+		}
+		raw = append(raw, jsonObj)
+	}
+	str, err := json.Marshal(raw)
+	if err != nil {
+		return "", err
+	}
+	return string(str), nil
+}
+
 //Get is used to select data from table
 //parameter raw is a json-object string like
 // {"$and":[{ "id": 2},{ "name": "张三"}]}
@@ -106,6 +174,11 @@ func (t *Table) WithFields(raw string) *Table {
 
 //Request is used to end a get operation and send request
 func (t *Table) Request() (string, error) {
+	return t.RequestContext(context.Background())
+}
+
+//RequestContext is the same as Request but bounded by ctx
+func (t *Table) RequestContext(ctx context.Context) (string, error) {
 	if t.op.Exec != util.RGet {
 		return "", errors.New("Not a get operation")
 	}
@@ -128,6 +201,10 @@ func (t *Table) Request() (string, error) {
 		var withFields interface{} = []string{}
 		t.op.Query = append([]interface{}{withFields}, t.op.Query...)
 	}
+	token, err := t.tokens.get(ctx, t.client, t.client.Auth.Owner, t.name)
+	if err != nil {
+		return "", err
+	}
 	strQuery, err := json.Marshal(t.op.Query)
 	if err != nil {
 		return "", err
@@ -135,30 +212,60 @@ func (t *Table) Request() (string, error) {
 	// fmt.Printf("Query string:%s\n",string(strQuery))
 
 	data := &TableGetJSON{}
-	nameInDB, err := t.client.GetNameInDB(t.client.Auth.Owner, t.name)
+	nameInDB, err := t.client.GetNameInDBContext(ctx, t.client.Auth.Owner, t.name)
 	if err != nil {
 		return "", err
 	}
-	if t.client.ServerInfo.Updated {
-		data.LedgerIndex = t.client.ServerInfo.LedgerIndex
-	} else {
-		ledgerIndex, err := t.client.GetLedgerVersion()
-		if err != nil {
-			return "", err
-		}
-		data.LedgerIndex = ledgerIndex
+	ledgerIndex, err := getLedgerIndex(ctx, t.client)
+	if err != nil {
+		return "", err
 	}
+	data.LedgerIndex = ledgerIndex
 	data.Tables = FormatTablesForGet(t.name, nameInDB)
 	data.Raw = string(strQuery)
 	data.Account = t.client.Auth.Address
 	data.Owner = t.client.Auth.Owner
-	return t.client.GetTableData(data, false)
+	result, err := t.client.GetTableDataContext(ctx, data, false)
+	if err != nil {
+		return "", err
+	}
+	if token != nil {
+		result, err = decryptLines(token, result)
+		if err != nil {
+			return "", err
+		}
+	}
+	return result, nil
+}
+
+//RequestRows is the same as Request but return the rows parsed
+func (t *Table) RequestRows() (*TableRows, error) {
+	return t.RequestRowsContext(context.Background())
+}
+
+//RequestRowsContext is the same as RequestRows but bounded by ctx
+func (t *Table) RequestRowsContext(ctx context.Context) (*TableRows, error) {
+	result, err := t.RequestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewTableRows(result)
 }
 
 //PrepareTx prepare tx json for submit
-func (t *Table) PrepareTx() (Signer, error) {
+func (t *Table) PrepareTx(ctx context.Context, offline *OfflineParams) (Signer, error) {
+	return t.prepareTx(t.newTxPreparer(ctx, offline))
+}
+
+func (t *Table) prepareTx(p *txPreparer) (Signer, error) {
+	if t.inTran {
+		return nil, errors.New("the operation is in a transaction, submit it by CommitTran")
+	}
+	if t.op.err != nil {
+		return nil, t.op.err
+	}
 	tx := &SQLStatement{}
-	seq, nameInDB, err := net.PrepareTable(t.client, t.name)
+	seq, nameInDB, err := p.prepareTable(t.name)
 	if err != nil {
 		// log.Println(err)
 		return nil, err
@@ -173,7 +280,18 @@ func (t *Table) PrepareTx() (Signer, error) {
 		// log.Println(err)
 		return nil, err
 	}
-	var valRaw = VariableLength(t.op.Raw) //fmt.Sprintf("%x", t.op.Raw)
+	raw := t.op.Raw
+	token, err := p.tableToken(t.client.Auth.Owner, t.name)
+	if err != nil {
+		return nil, err
+	}
+	if token != nil {
+		raw, err = encryptRaw(token, raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var valRaw = VariableLength(raw) //fmt.Sprintf("%x", t.op.Raw)
 	tx.TransactionType = SQLSTATEMENT
 	tx.Tables = FormatTables(t.name, nameInDB)
 	tx.OpType = t.op.Exec
@@ -181,27 +299,9 @@ func (t *Table) PrepareTx() (Signer, error) {
 	tx.Account = *account
 	tx.Owner = *owner
 	tx.Sequence = seq
-	var fee int64 = 10
-	if t.client.ServerInfo.Updated {
-		last := uint32(t.client.ServerInfo.LedgerIndex + 20)
-		tx.LastLedgerSequence = &last
-		fee = int64(t.client.ServerInfo.ComputeFee())
-	} else {
-		ledgerIndex, err := t.client.GetLedgerVersion()
-		if err != nil {
-			return nil, err
-		}
-		last := uint32(ledgerIndex + 20)
-		tx.LastLedgerSequence = &last
-
-		fee = 50
-	}
-
-	fee += util.GetExtraFee(t.op.Raw, t.client.ServerInfo.DropsPerByte)
-	finalFee, err := NewNativeValue(fee)
+	err = p.prepareLastLedgerAndFee(&tx.TxBase, util.GetExtraFee(raw, t.client.ServerInfo.Snapshot().DropsPerByte))
 	if err != nil {
 		return nil, err
 	}
-	tx.Fee = *finalFee
 	return tx, nil
 }
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	. "github.com/ChainSQL/go-chainsql-api/data"
+)
+
+func TestTableOpRaw(t *testing.T) {
+	c := NewChainsql()
+	for _, test := range []struct {
+		table *Table
+		raw   string
+	}{
+		{c.Table("t1").Update(`{"age":20}`, `{"id":1}`), `[{"age":20},{"id":1}]`},
+		{c.Table("t1").Update(`{"age":20}`, ""), `[{"age":20},{}]`},
+		{c.Table("t1").Delete(`{"id":1}`), `[{"id":1}]`},
+		{c.Table("t1").Delete(""), `[{}]`},
+	} {
+		if test.table.op.err != nil || test.table.op.Raw != test.raw {
+			t.Fatalf("expected %s, got %s:%v", test.raw, test.table.op.Raw, test.table.op.err)
+		}
+	}
+
+	if table := c.Table("t1").Update("", `{"id":1}`); table.op.err == nil {
+		t.Fatalf("expected an empty set to fail, got %s", table.op.Raw)
+	}
+	if table := c.Table("t1").Delete(`{"id":`); table.op.err == nil {
+		t.Fatal("expected an invalid condition to fail")
+	}
+}
+
+func TestTableInTran(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+	params := &OfflineParams{Sequence: 1, Fee: 12, NameInDB: "A1B2C3"}
+
+	// the table got before BeginTran joins the transaction
+	table := c.Table("t1")
+	c.BeginTran()
+	table.Insert(`[{"id":1}]`)
+	c.Table("t1").Delete(`{"id":2}`)
+	if _, err := table.SignOffline(params); err == nil {
+		t.Fatal("expected submitting an operation in a transaction to fail")
+	}
+	tran := c.CommitTran()
+	if tran.op.err != nil || len(tran.op.tran.statements) != 2 {
+		t.Fatalf("expected 2 statements, got %+v", tran.op)
+	}
+
+	// the operations after CommitTran are submitted on their own
+	if _, err := table.Insert(`[{"id":3}]`).SignOffline(params); err != nil {
+		t.Fatal(err)
+	}
+	if len(tran.op.tran.statements) != 2 {
+		t.Fatal("expected the transaction committed unchanged")
+	}
+}
+
+func TestDropRenameTableRaw(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+	const nameInDB = "A1B2C3D4E5F60718293A4B5C6D7E8F9012345678"
+	params := &OfflineParams{Sequence: 1, Fee: 12, NameInDB: nameInDB}
+	// TableNewName is a VL field of code 53
+	newNameField := "7035" + hex.EncodeToString([]byte{2}) + strings.ToUpper(hex.EncodeToString([]byte("t2")))
+
+	for _, test := range []struct {
+		op      func() *Chainsql
+		opType  uint16
+		newName string
+	}{
+		{func() *Chainsql { return c.DropTable("t1") }, 2, ""},
+		{func() *Chainsql { return c.RenameTable("t1", "t2") }, 3, "t2"},
+	} {
+		// the op is kept on c until the next one
+		signed, err := test.op().SignOffline(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, _, err := parseSignedTx(signed.TxBlob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		listSet, ok := tx.(*TableListSet)
+		if !ok || listSet.TransactionType != TABLE_LIST_SET || listSet.OpType != test.opType || len(listSet.Tables) != 1 {
+			t.Fatalf("unexpected tx %+v", tx)
+		}
+		table := listSet.Tables[0].Table
+		if string(table.TableName.Bytes()) != "t1" || table.NameInDB.String() != nameInDB || string(table.TableNewName.Bytes()) != test.newName {
+			t.Fatalf("unexpected table %s %s %s", table.TableName.Bytes(), table.NameInDB, table.TableNewName.Bytes())
+		}
+		if strings.Contains(signed.TxBlob, newNameField) != (test.newName != "") {
+			t.Fatalf("expected TableNewName %q in %s", test.newName, signed.TxBlob)
+		}
+	}
+
+	if _, err := c.DropTable("t1").SignOffline(&OfflineParams{Sequence: 1, Fee: 12}); err == nil {
+		t.Fatal("expected the missing NameInDB to fail")
+	}
+}
+
+func TestParseGrantFlags(t *testing.T) {
+	for flagsJSON, expected := range map[string]TransactionFlag{
+		`{"select":true}`:  0x00010000,
+		`{"insert":true}`:  0x00020000,
+		`{"update":true}`:  0x00040000,
+		`{"delete":true}`:  0x00080000,
+		`{"execute":true}`: 0x00100000,
+		`{"select":true,"insert":true,"update":true,"delete":true,"execute":true}`: 0x001F0000,
+		`{"select":true,"insert":false,"delete":true}`:                             0x00090000,
+		`{"select":false}`: 0,
+		`{}`:               0,
+	} {
+		flags, err := parseGrantFlags(flagsJSON)
+		if err != nil || flags != expected {
+			t.Fatalf("expected %s to be %#x, got %#x:%v", flagsJSON, expected, flags, err)
+		}
+	}
+	for _, flagsJSON := range []string{`{"drop":true}`, `{"Select":true}`, `{"select":"yes"}`, `{"select":1}`, `["select"]`, `select`, ``} {
+		if _, err := parseGrantFlags(flagsJSON); err == nil {
+			t.Fatalf("expected %q to fail", flagsJSON)
+		}
+	}
+}
+
+func TestGrantRaw(t *testing.T) {
+	c := NewChainsql()
+	c.As(testAddress, testSecret)
+	const nameInDB = "A1B2C3D4E5F60718293A4B5C6D7E8F9012345678"
+	params := &OfflineParams{Sequence: 1, Fee: 12, NameInDB: nameInDB}
+
+	for _, test := range []struct {
+		op    func() *Chainsql
+		flags TransactionFlag
+	}{
+		{func() *Chainsql { return c.Grant("t1", testUser, `{"select":true,"insert":true}`) }, 0x00030000},
+		{func() *Chainsql { return c.Revoke("t1", testUser) }, 0},
+	} {
+		signed, err := test.op().SignOffline(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, _, err := parseSignedTx(signed.TxBlob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		listSet, ok := tx.(*TableListSet)
+		if !ok || listSet.OpType != 11 || listSet.User == nil || listSet.User.String() != testUser {
+			t.Fatalf("unexpected tx %+v", tx)
+		}
+		// revoking is a grant of no flags, the Flags field is kept
+		if listSet.Flags == nil || *listSet.Flags != test.flags {
+			t.Fatalf("expected flags %#x, got %v", test.flags, listSet.Flags)
+		}
+		if table := listSet.Tables[0].Table; string(table.TableName.Bytes()) != "t1" || table.NameInDB.String() != nameInDB {
+			t.Fatalf("unexpected table %+v", table)
+		}
+	}
+
+	if _, err := c.Grant("t1", testUser, `{"drop":true}`).SignOffline(params); err == nil {
+		t.Fatal("expected the unknown flag to fail")
+	}
+	if _, err := c.Grant("t1", "abc", `{"select":true}`).SignOffline(params); err == nil {
+		t.Fatal("expected the invalid user to fail")
+	}
+}
+
+func TestGetTableAuth(t *testing.T) {
+	node := newFakeNode(t)
+	defer node.close()
+	requests := make(chan map[string]interface{}, 4)
+	node.onCommand = func(req map[string]interface{}) []interface{} {
+		if req["command"] != "table_auth" {
+			return nil
+		}
+		requests <- req
+		return []interface{}{response(req, map[string]interface{}{
+			"owner":     req["owner"],
+			"tablename": req["tablename"],
+			"users":     []interface{}{map[string]interface{}{"account": testUser, "select": true}},
+		})}
+	}
+	c := newTestChainsql(t, node)
+	defer c.Disconnect()
+
+	auth, err := c.GetTableAuth(testAddress, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(auth, testUser) {
+		t.Fatalf("unexpected auth %s", auth)
+	}
+	req := <-requests
+	if req["owner"] != testAddress || req["tablename"] != "t1" {
+		t.Fatalf("unexpected request %v", req)
+	}
+	if _, ok := req["accounts"]; ok {
+		t.Fatalf("expected no accounts, got %v", req)
+	}
+
+	if _, err := c.GetTableAuth(testAddress, "t1", testUser, testAddress); err != nil {
+		t.Fatal(err)
+	}
+	req = <-requests
+	if accounts, ok := req["accounts"].([]interface{}); !ok || len(accounts) != 2 || accounts[0] != testUser || accounts[1] != testAddress {
+		t.Fatalf("unexpected accounts %v", req["accounts"])
+	}
+}
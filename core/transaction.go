@@ -0,0 +1,137 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	. "github.com/ChainSQL/go-chainsql-api/data"
+	"github.com/ChainSQL/go-chainsql-api/util"
+)
+
+// sqlTran collects the table operations between BeginTran and CommitTran
+type sqlTran struct {
+	statements []*tranStatement
+	err        error
+	mutex      *sync.Mutex
+}
+
+// tranStatement is a table operation in a transaction
+type tranStatement struct {
+	name  string
+	owner string
+	op    OpInfo
+}
+
+func newSQLTran() *sqlTran {
+	return &sqlTran{
+		statements: make([]*tranStatement, 0),
+		mutex:      new(sync.Mutex),
+	}
+}
+
+func (s *sqlTran) add(name string, owner string, op OpInfo) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if op.err != nil && s.err == nil {
+		s.err = op.err
+	}
+	s.statements = append(s.statements, &tranStatement{
+		name:  name,
+		owner: owner,
+		op:    op,
+	})
+}
+
+//BeginTran begin a transaction, the insert/update/delete operations of the
+//tables got from c.Table until CommitTran will be submitted atomically
+func (c *Chainsql) BeginTran() {
+	c.tran = newSQLTran()
+}
+
+//CommitTran end a transaction, call Submit or SubmitAsync to submit it
+func (c *Chainsql) CommitTran() *Chainsql {
+	c.op = &tableListOp{
+		tran: c.tran,
+	}
+	if c.tran == nil {
+		c.op.err = errors.New("CommitTran called without BeginTran")
+	} else if len(c.tran.statements) == 0 {
+		c.op.err = errors.New("No operation in transaction")
+	} else {
+		c.op.err = c.tran.err
+	}
+	c.tran = nil
+	return c
+}
+
+func (c *Chainsql) prepareSQLTransaction(p *txPreparer) (*SQLTransaction, error) {
+	account, err := NewAccountFromAddress(c.client.Auth.Address)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := p.accountSequence()
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[TableKey]bool)
+	for _, st := range c.op.tran.statements {
+		tables[TableKey{st.owner, st.name}] = true
+	}
+	p.tables = len(tables)
+	namesInDB := make(map[TableKey]string)
+	tokens := make(map[TableKey][]byte)
+	statements := make([]*Statement, 0, len(c.op.tran.statements))
+	for _, st := range c.op.tran.statements {
+		key := TableKey{st.owner, st.name}
+		nameInDB, ok := namesInDB[key]
+		if !ok {
+			nameInDB, err = p.tableNameInDB(st.owner, st.name)
+			if err != nil {
+				return nil, err
+			}
+			namesInDB[key] = nameInDB
+			tokens[key], err = p.tableToken(st.owner, st.name)
+			if err != nil {
+				return nil, err
+			}
+		}
+		owner, err := NewAccountFromAddress(st.owner)
+		if err != nil {
+			return nil, err
+		}
+		raw := st.op.Raw
+		if token := tokens[key]; token != nil {
+			raw, err = encryptRaw(token, raw)
+			if err != nil {
+				return nil, err
+			}
+		}
+		valRaw := VariableLength(raw)
+		statements = append(statements, &Statement{
+			TransactionType: SQLSTATEMENT,
+			Account:         *account,
+			Owner:           *owner,
+			Tables:          FormatTables(st.name, nameInDB),
+			OpType:          st.op.Exec,
+			Raw:             &valRaw,
+		})
+	}
+	jsonStatements, err := json.Marshal(statements)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &SQLTransaction{}
+	tx.TransactionType = SQLTRANSACTION
+	tx.Statements = VariableLength(jsonStatements)
+	tx.NeedVerify = 1
+	tx.Account = *account
+	tx.Sequence = seq
+	err = p.prepareLastLedgerAndFee(&tx.TxBase, util.GetExtraFee(string(jsonStatements), c.client.ServerInfo.Snapshot().DropsPerByte))
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
@@ -15,45 +15,134 @@ type Account struct {
 	PrivateKey   string `json:"privateKey"`
 }
 
-func GenerateAccount(args ...string) (string, error) {
-	var seed Hash
+// AccountOption configures GenerateAccount
+type AccountOption func(*accountOptions)
+
+type accountOptions struct {
+	secret  string
+	keyType KeyType
+}
+
+// WithSecret recreate the account from secret instead of generating one,
+// the algorithm is that of the secret
+func WithSecret(secret string) AccountOption {
+	return func(o *accountOptions) {
+		o.secret = secret
+	}
+}
+
+// WithAlgorithm generate the account of keyType, ECDSA by default,
+// Ed25519 and SoftGM are supported too
+func WithAlgorithm(keyType KeyType) AccountOption {
+	return func(o *accountOptions) {
+		o.keyType = keyType
+	}
+}
+
+// GenerateAccount generate an account with opts and return it in json format,
+// an ECDSA account is generated without options
+func GenerateAccount(opts ...AccountOption) (string, error) {
+	generated, err := GenerateAccountKeys(opts...)
+	if err != nil {
+		return "", err
+	}
+	jsonStr, err := json.Marshal(generated)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonStr), nil
+}
+
+// GenerateAccountFromSecret recreate the account of secret in json format,
+// it is kept for the callers of the former GenerateAccount(secret).
+//
+// Deprecated: use GenerateAccount(WithSecret(secret))
+func GenerateAccountFromSecret(secret string) (string, error) {
+	return GenerateAccount(WithSecret(secret))
+}
+
+// GenerateAccountKeys is the same as GenerateAccount but return the account parsed,
+// the secret of an Ed25519 account is a family seed with the Ed25519 prefix,
+// that of a SoftGM account is its SM2 private key instead of a family seed
+func GenerateAccountKeys(opts ...AccountOption) (*Account, error) {
+	o := &accountOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	var key Key
+	var secret string
 	var err error
-	var key *ecdsaKey
-	if len(args) == 1 {
-		seed, err = NewRippleHash(args[0])
-		if err != nil {
-			return "", err
+	switch {
+	case o.secret != "":
+		key, _, err = NewKeyFromSecret(o.secret)
+		secret = o.secret
+	case o.keyType == SoftGM:
+		key, err = NewSM2Key(nil)
+		if err == nil {
+			secret, err = hashString(NewAccountPrivateKey(key.Private(nil)))
 		}
-	} else {
+	case o.keyType == ECDSA || o.keyType == Ed25519:
 		rndBytes := make([]byte, 16)
 		if _, err := rand.Read(rndBytes); err != nil {
-			return "", err
+			return nil, err
 		}
-		seed, err = GenerateFamilySeed(string(rndBytes))
-		if err != nil {
-			return "", err
+		seed := Sha512Quarter(rndBytes)
+		if o.keyType == Ed25519 {
+			key, err = NewEd25519Key(seed)
+			secret = Ed25519Secret(seed)
+		} else {
+			key, err = NewECDSAKey(seed)
+			if err == nil {
+				secret, err = hashString(NewFamilySeed(seed))
+			}
 		}
+	default:
+		err = fmt.Errorf("Unsupported key type %s", o.keyType)
 	}
-	key, err = NewECDSAKey(seed.Payload())
 	if err != nil {
 		log.Println(err)
-		return "", err
+		return nil, err
 	}
 
-	sequenceZero := uint32(0)
-	account, _ := AccountId(key, &sequenceZero)
-	publicKey, _ := AccountPublicKey(key, &sequenceZero)
-	generated := Account{
+	sequence := AccountKeySequence(key)
+	account, _ := AccountId(key, sequence)
+	publicKey, _ := AccountPublicKey(key, sequence)
+	return &Account{
 		Address:      account.String(),
 		PublicKey:    publicKey.String(),
-		PublicKeyHex: fmt.Sprintf("%X", key.Public(&sequenceZero)),
-		PrivateKey:   seed.String(),
-	}
-	jsonStr, err := json.Marshal(generated)
+		PublicKeyHex: fmt.Sprintf("%X", key.Public(sequence)),
+		PrivateKey:   secret,
+	}, nil
+}
+
+func hashString(h Hash, err error) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return string(jsonStr), nil
+	return h.String(), nil
+}
+
+// NewKeyFromSecret create the key of an account secret, which is a family seed
+// for ECDSA and Ed25519, or the private key for SoftGM
+func NewKeyFromSecret(secret string) (Key, KeyType, error) {
+	if seed := ed25519Seed(secret); seed != nil {
+		key, err := NewEd25519Key(seed)
+		return key, Ed25519, err
+	}
+	hash, err := NewRippleHash(secret)
+	if err != nil {
+		return nil, ECDSA, err
+	}
+	switch hash.Version() {
+	case RIPPLE_FAMILY_SEED:
+		key, err := NewECDSAKey(hash.Payload())
+		return key, ECDSA, err
+	case RIPPLE_ACCOUNT_PRIVATE:
+		key, err := NewSM2KeyFromPrivate(hash.Payload())
+		return key, SoftGM, err
+	default:
+		return nil, ECDSA, fmt.Errorf("Unknown secret format")
+	}
 }
 
 func ValidationCreate() (string, error) {
@@ -65,6 +154,8 @@ func ValidationCreate() (string, error) {
 	return string(jsonStr), nil
 }
 
+//GetAccountInfo is kept for compatibility, crypto has no connection to request
+//account_info with, use Chainsql.GetAccountInfo for the info of an account
 func GetAccountInfo(address string) (string, error) {
 	generated := Account{}
 	jsonStr, err := json.Marshal(generated)
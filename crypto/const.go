@@ -21,6 +21,7 @@ const (
 	RIPPLE_ACCOUNT_PUBLIC  HashVersion = 35
 )
 
+// the public keys are 65 bytes for SM2
 var hashTypes = [...]struct {
 	Description       string
 	Prefix            byte
@@ -28,9 +29,9 @@ var hashTypes = [...]struct {
 	MaximumCharacters int
 }{
 	RIPPLE_ACCOUNT_ID:      {"Short name for sending funds to an account.", 'r', 20, 35},
-	RIPPLE_NODE_PUBLIC:     {"Validation public key for node.", 'n', 33, 53},
+	RIPPLE_NODE_PUBLIC:     {"Validation public key for node.", 'n', 65, 96},
 	RIPPLE_NODE_PRIVATE:    {"Validation private key for node.", 'p', 32, 52},
 	RIPPLE_FAMILY_SEED:     {"Family seed.", 's', 16, 29},
 	RIPPLE_ACCOUNT_PRIVATE: {"Account private key.", 'p', 32, 52},
-	RIPPLE_ACCOUNT_PUBLIC:  {"Account public key.", 'a', 33, 53},
+	RIPPLE_ACCOUNT_PUBLIC:  {"Account public key.", 'a', 65, 96},
 }
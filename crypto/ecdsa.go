@@ -58,11 +58,13 @@ func (k *ecdsaKey) Id(sequence *uint32) []byte {
 	return Sha256RipeMD160(k.Public(sequence))
 }
 
+// Private return the private key padded to 32 bytes,
+// D may be shorter with leading zeros
 func (k *ecdsaKey) Private(sequence *uint32) []byte {
 	if sequence == nil {
-		return k.D.Bytes()
+		return k.D.FillBytes(make([]byte, btcec.PrivKeyBytesLen))
 	}
-	return k.generateKey(*sequence).D.Bytes()
+	return k.generateKey(*sequence).D.FillBytes(make([]byte, btcec.PrivKeyBytesLen))
 }
 
 func (k *ecdsaKey) Public(sequence *uint32) []byte {
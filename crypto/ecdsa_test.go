@@ -0,0 +1,35 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestECDSAShortPrivateKey(t *testing.T) {
+	// find an account key with a leading zero byte
+	var key *ecdsaKey
+	var sequence uint32
+	for i := uint32(0); key == nil; i++ {
+		seed := make([]byte, 16)
+		binary.BigEndian.PutUint32(seed, i)
+		k, err := NewECDSAKey(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.generateKey(sequence).D.BitLen() <= 248 {
+			key = k
+		}
+	}
+	private := key.Private(&sequence)
+	if len(private) != 32 || private[0] != 0 {
+		t.Fatalf("expected the private key padded to 32 bytes, got %X", private)
+	}
+	hash := Sha512Half([]byte("msg"))
+	signature, err := SignWithKey(key, &sequence, hash, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(key.Public(&sequence), hash, nil, signature); !ok || err != nil {
+		t.Fatalf("expected the signature verified:%v", err)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"crypto/rand"
 )
 
+// ed25519SeedPrefix replaces the version byte of the family seeds of Ed25519 keys
+var ed25519SeedPrefix = []byte{0x01, 0xE1, 0x4B}
+
 type ed25519key struct {
 	priv ed25519.PrivateKey
 }
@@ -42,3 +45,18 @@ func NewEd25519Key(seed []byte) (*ed25519key, error) {
 	}
 	return &ed25519key{priv: priv}, nil
 }
+
+// Ed25519Secret encode the family seed of an Ed25519 key as the account secret,
+// which is told from the secrets of ECDSA keys by its prefix
+func Ed25519Secret(seed []byte) string {
+	return Base58Encode(append(append([]byte(nil), ed25519SeedPrefix...), seed...), ALPHABET)
+}
+
+// ed25519Seed return the family seed of an Ed25519 secret, nil for other secrets
+func ed25519Seed(secret string) []byte {
+	decoded, err := Base58Decode(secret, ALPHABET)
+	if err != nil || len(decoded) != len(ed25519SeedPrefix)+16+4 || !bytes.HasPrefix(decoded, ed25519SeedPrefix) {
+		return nil
+	}
+	return decoded[len(ed25519SeedPrefix) : len(decoded)-4]
+}
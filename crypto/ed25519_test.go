@@ -0,0 +1,56 @@
+package crypto
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEd25519Account(t *testing.T) {
+	account, err := GenerateAccountKeys(WithAlgorithm(Ed25519))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, keyType, err := NewKeyFromSecret(account.PrivateKey)
+	if err != nil || keyType != Ed25519 {
+		t.Fatalf("unexpected key type %s:%v", keyType, err)
+	}
+	if AccountKeySequence(key) != nil {
+		t.Fatal("expected no account family of Ed25519 keys")
+	}
+	recreated, err := GenerateAccountKeys(WithSecret(account.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *recreated != *account {
+		t.Fatalf("expected %+v, got %+v", account, recreated)
+	}
+	if str, err := GenerateAccountFromSecret(account.PrivateKey); err != nil || !strings.Contains(str, account.Address) {
+		t.Fatalf("expected the account %s recreated, got %s:%v", account.Address, str, err)
+	}
+	public := key.Public(nil)
+	if public[0] != 0xED || B2H(public) != account.PublicKeyHex {
+		t.Fatalf("unexpected public key %X", public)
+	}
+
+	msg := []byte("hello chainsql")
+	sig, err := SignWithKey(key, AccountKeySequence(key), Sha512Half(msg), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(public, Sha512Half(msg), msg, sig); err != nil || !ok {
+		t.Fatalf("expected the signature to be valid:%v", err)
+	}
+
+	seed := bytes.Repeat([]byte{1}, 16)
+	if got := ed25519Seed(Ed25519Secret(seed)); !bytes.Equal(got, seed) {
+		t.Fatalf("expected seed %X, got %X", seed, got)
+	}
+	family, err := NewFamilySeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ed25519Seed(family.String()) != nil {
+		t.Fatal("expected an ECDSA secret not to be an Ed25519 seed")
+	}
+}
@@ -0,0 +1,121 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// TokenLength is the length of the symmetric table tokens
+const TokenLength = 32
+
+// Decrypter decrypts the data encrypted to its public key by EncryptTo,
+// the signers of the accounts operating confidential tables implement it
+type Decrypter interface {
+	Decrypt(data []byte) ([]byte, error)
+}
+
+// EncryptTo encrypt data to publicKey, by ECIES for ECDSA keys and
+// SM2 encryption for SM2 keys, Ed25519 keys are not supported
+func EncryptTo(publicKey, data []byte) ([]byte, error) {
+	if len(publicKey) == 0 {
+		return nil, fmt.Errorf("Unknown public key format")
+	}
+	switch publicKey[0] {
+	case SM2PublicKeyPrefix:
+		if len(publicKey) != sm2PublicKeyLength {
+			return nil, fmt.Errorf("Wrong public key length: %d", len(publicKey))
+		}
+		pub := &sm2.PublicKey{
+			Curve: sm2.P256Sm2(),
+			X:     big.NewInt(0).SetBytes(publicKey[1:33]),
+			Y:     big.NewInt(0).SetBytes(publicKey[33:]),
+		}
+		return sm2.Encrypt(pub, data, rand.Reader, sm2.C1C3C2)
+	case 0x02, 0x03:
+		pub, err := btcec.ParsePubKey(publicKey, btcec.S256())
+		if err != nil {
+			return nil, err
+		}
+		return btcec.Encrypt(pub, data)
+	case 0xED:
+		return nil, fmt.Errorf("Encryption is not supported by Ed25519 keys")
+	default:
+		return nil, fmt.Errorf("Unknown public key format")
+	}
+}
+
+// DecryptWithKey decrypt the data encrypted by EncryptTo to the public key of key
+func DecryptWithKey(key Key, sequence *uint32, data []byte) ([]byte, error) {
+	switch k := key.(type) {
+	case *sm2Key:
+		return sm2.Decrypt(k.priv, data, sm2.C1C3C2)
+	case *ecdsaKey:
+		priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), key.Private(sequence))
+		return btcec.Decrypt(priv, data)
+	default:
+		return nil, fmt.Errorf("Encryption is not supported by Ed25519 keys")
+	}
+}
+
+func (k *keySigner) Decrypt(data []byte) ([]byte, error) {
+	return DecryptWithKey(k.key, k.sequence, data)
+}
+
+// NewToken generate a random table token
+func NewToken() ([]byte, error) {
+	token := make([]byte, TokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// TokenEncrypt encrypt plain with token the way the ChainSQL nodes decrypt the
+// raw of confidential tables, by AES-256 in ECB mode with PKCS#7 padding
+func TokenEncrypt(token, plain []byte) ([]byte, error) {
+	block, err := tokenCipher(token)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(plain)%aes.BlockSize
+	out := append(append([]byte(nil), plain...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	for i := 0; i < len(out); i += aes.BlockSize {
+		block.Encrypt(out[i:i+aes.BlockSize], out[i:i+aes.BlockSize])
+	}
+	return out, nil
+}
+
+// TokenDecrypt decrypt the output of TokenEncrypt and check its padding
+func TokenDecrypt(token, encrypted []byte) ([]byte, error) {
+	block, err := tokenCipher(token)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted) == 0 || len(encrypted)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("Wrong cipher text length: %d", len(encrypted))
+	}
+	plain := make([]byte, len(encrypted))
+	for i := 0; i < len(plain); i += aes.BlockSize {
+		block.Decrypt(plain[i:i+aes.BlockSize], encrypted[i:i+aes.BlockSize])
+	}
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, fmt.Errorf("Wrong token or corrupted cipher text")
+	}
+	return plain[:len(plain)-padding], nil
+}
+
+// tokenCipher is the AES-256 cipher keyed by token
+func tokenCipher(token []byte) (cipher.Block, error) {
+	if len(token) != TokenLength {
+		return nil, fmt.Errorf("Wrong token length: %d", len(token))
+	}
+	return aes.NewCipher(token)
+}
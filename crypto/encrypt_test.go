@@ -0,0 +1,82 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptTo(t *testing.T) {
+	data := []byte("table token")
+	for _, keyType := range []KeyType{ECDSA, SoftGM} {
+		account, err := GenerateAccountKeys(WithAlgorithm(keyType))
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, _, err := NewKeyFromSecret(account.PrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seq := AccountKeySequence(key)
+		encrypted, err := EncryptTo(key.Public(seq), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := DecryptWithKey(key, seq, encrypted)
+		if err != nil || !bytes.Equal(decrypted, data) {
+			t.Fatalf("%s: expected %s, got %s:%v", keyType, data, decrypted, err)
+		}
+
+		other, _, _ := NewKeyFromSecret(generateSecret(t, keyType))
+		if _, err := DecryptWithKey(other, AccountKeySequence(other), encrypted); err == nil {
+			t.Fatalf("%s: expected decrypting with another key to fail", keyType)
+		}
+	}
+
+	account, _ := GenerateAccountKeys(WithAlgorithm(Ed25519))
+	key, _, _ := NewKeyFromSecret(account.PrivateKey)
+	if _, err := EncryptTo(key.Public(nil), data); err == nil {
+		t.Fatal("expected Ed25519 keys to fail")
+	}
+}
+
+func generateSecret(t *testing.T, keyType KeyType) string {
+	account, err := GenerateAccountKeys(WithAlgorithm(keyType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account.PrivateKey
+}
+
+func TestTokenEncrypt(t *testing.T) {
+	// the ECB-AES256 vectors of NIST SP 800-38A F.1.5, padded by a full block
+	token, _ := H2B("603DEB1015CA71BE2B73AEF0857D77811F352C073B6108D72D9810A30914DFF4")
+	plain, _ := H2B("6BC1BEE22E409F96E93D7E117393172AAE2D8A571E03AC9C9EB76FAC45AF8E51")
+	expected, _ := H2B("F3EED1BDB5D2A03C064B5A7E3DB181F8591CCB10D410ED26DC5BA74A31362870")
+	encrypted, err := TokenEncrypt(token, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(encrypted) != len(plain)+16 || !bytes.Equal(encrypted[:len(plain)], expected) {
+		t.Fatalf("expected %X, got %X", expected, encrypted)
+	}
+	decrypted, err := TokenDecrypt(token, encrypted)
+	if err != nil || !bytes.Equal(decrypted, plain) {
+		t.Fatalf("expected %X, got %X:%v", plain, decrypted, err)
+	}
+
+	raw := []byte(`[{"id":1,"name":"Alice"}]`)
+	encrypted, _ = TokenEncrypt(token, raw)
+	if decrypted, err := TokenDecrypt(token, encrypted); err != nil || !bytes.Equal(decrypted, raw) {
+		t.Fatalf("expected %s, got %s:%v", raw, decrypted, err)
+	}
+	wrong, _ := NewToken()
+	if _, err := TokenDecrypt(wrong, encrypted); err == nil {
+		t.Fatal("expected decrypting with another token to fail")
+	}
+	if _, err := TokenDecrypt(token, encrypted[1:]); err == nil {
+		t.Fatal("expected a truncated cipher text to fail")
+	}
+	if _, err := TokenEncrypt(token[:16], raw); err == nil {
+		t.Fatal("expected a short token to fail")
+	}
+}
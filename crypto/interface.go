@@ -17,3 +17,40 @@ type Hash interface {
 	Clone() Hash
 	MarshalText() ([]byte, error)
 }
+
+// KeyType is the algorithm of a key
+type KeyType int
+
+const (
+	ECDSA   KeyType = 0
+	Ed25519 KeyType = 1
+	// SoftGM is the SM2 key of the nodes in GM mode, hashed by SM3
+	SoftGM KeyType = 2
+)
+
+func (keyType KeyType) String() string {
+	switch keyType {
+	case ECDSA:
+		return "ECDSA"
+	case Ed25519:
+		return "Ed25519"
+	case SoftGM:
+		return "softGMAlg"
+	default:
+		return "unknown key type"
+	}
+}
+
+func (keyType KeyType) MarshalText() ([]byte, error) {
+	return []byte(keyType.String()), nil
+}
+
+// AccountKeySequence is the sequence of the account key derived from key,
+// nil for the keys without account families like Ed25519
+func AccountKeySequence(key Key) *uint32 {
+	if _, ok := key.(*ecdsaKey); ok {
+		sequenceZero := uint32(0)
+		return &sequenceZero
+	}
+	return nil
+}
@@ -18,8 +18,21 @@ func Sign(privateKey, hash, msg []byte) ([]byte, error) {
 	}
 }
 
+// SignWithKey sign by the algorithm of key, SM2 keys sign msg hashed by SM3
+func SignWithKey(key Key, sequence *uint32, hash, msg []byte) ([]byte, error) {
+	if k, ok := key.(*sm2Key); ok {
+		return signSM2(k.priv, msg)
+	}
+	return Sign(key.Private(sequence), hash, msg)
+}
+
 func Verify(publicKey, hash, msg, signature []byte) (bool, error) {
+	if len(publicKey) == 0 {
+		return false, fmt.Errorf("Unknown public key format")
+	}
 	switch publicKey[0] {
+	case SM2PublicKeyPrefix:
+		return verifySM2(publicKey, signature, msg)
 	case 0xED:
 		return verifyEd25519(publicKey, signature, msg)
 	case 0x02, 0x03:
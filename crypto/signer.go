@@ -0,0 +1,164 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Signer signs for an account without exposing its private key,
+// which may be kept in memory, or by an HSM or KMS out of the process
+type Signer interface {
+	// PublicKey return the public key, the algorithm is told by its prefix
+	PublicKey() ([]byte, error)
+	// Sign sign msg, hash is the Sha512Half of msg signed by ECDSA keys
+	Sign(hash, msg []byte) ([]byte, error)
+}
+
+// NewSigner create the signer of credential, which is a secret string or a Signer
+func NewSigner(credential interface{}) (Signer, error) {
+	switch v := credential.(type) {
+	case Signer:
+		return v, nil
+	case string:
+		return NewSecretSigner(v)
+	default:
+		return nil, fmt.Errorf("Unsupported credential %T", credential)
+	}
+}
+
+type keySigner struct {
+	key      Key
+	sequence *uint32
+}
+
+// NewKeySigner is the in-memory signer of the account key of sequence
+func NewKeySigner(key Key, sequence *uint32) Signer {
+	return &keySigner{key: key, sequence: sequence}
+}
+
+// NewSecretSigner is the in-memory signer of an account secret
+func NewSecretSigner(secret string) (Signer, error) {
+	key, _, err := NewKeyFromSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySigner(key, AccountKeySequence(key)), nil
+}
+
+func (k *keySigner) PublicKey() ([]byte, error) {
+	return k.key.Public(k.sequence), nil
+}
+
+func (k *keySigner) Sign(hash, msg []byte) ([]byte, error) {
+	return SignWithKey(k.key, k.sequence, hash, msg)
+}
+
+// CommandSigner runs an external process for each request, which reads
+// a request from stdin and writes the response to stdout in JSON:
+//
+//	{"method":"public_key"} => {"public_key":"<hex>"}
+//	{"method":"sign","hash":"<hex>","message":"<hex>"} => {"signature":"<hex>"}
+//
+// A response of {"error":"<message>"} or a non-zero exit status fails the request.
+// The process is usually a bridge to a KMS or HSM
+type CommandSigner struct {
+	// Path and Args are the command to run
+	Path string
+	Args []string
+	// Timeout bounds each run of the command, no limit if 0
+	Timeout time.Duration
+
+	mutex     sync.Mutex
+	publicKey []byte
+}
+
+type commandRequest struct {
+	Method  string `json:"method"`
+	Hash    string `json:"hash,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type commandResponse struct {
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+	Error     string `json:"error"`
+}
+
+// NewCommandSigner create the signer running the command of path with args
+func NewCommandSigner(path string, args ...string) *CommandSigner {
+	return &CommandSigner{Path: path, Args: args}
+}
+
+// PublicKey request the public key once, it is cached afterwards
+func (c *CommandSigner) PublicKey() ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.publicKey != nil {
+		return c.publicKey, nil
+	}
+	response, err := c.run(&commandRequest{Method: "public_key"})
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := hex.DecodeString(response.PublicKey)
+	if err != nil || len(publicKey) == 0 {
+		return nil, fmt.Errorf("Invalid public key from signer: %q", response.PublicKey)
+	}
+	c.publicKey = publicKey
+	return publicKey, nil
+}
+
+func (c *CommandSigner) Sign(hash, msg []byte) ([]byte, error) {
+	response, err := c.run(&commandRequest{
+		Method:  "sign",
+		Hash:    B2H(hash),
+		Message: B2H(msg),
+	})
+	if err != nil {
+		return nil, err
+	}
+	signature, err := hex.DecodeString(response.Signature)
+	if err != nil || len(signature) == 0 {
+		return nil, fmt.Errorf("Invalid signature from signer: %q", response.Signature)
+	}
+	return signature, nil
+}
+
+func (c *CommandSigner) run(request *commandRequest) (*commandResponse, error) {
+	ctx := context.Background()
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Path, c.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("signer %s: %s: %s", request.Method, err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return nil, fmt.Errorf("signer %s: %s", request.Method, err)
+	}
+	response := &commandResponse{}
+	if err := json.Unmarshal(stdout.Bytes(), response); err != nil {
+		return nil, fmt.Errorf("signer %s: %s", request.Method, err)
+	}
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+	return response, nil
+}
@@ -0,0 +1,95 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+const helperSecret = "xnoPBzXtMeMyMHUVTgbuqAfg1SUTb"
+
+// TestHelperSigner is the external signer run by CommandSigner in the tests
+func TestHelperSigner(t *testing.T) {
+	mode := os.Getenv("SIGNER_HELPER")
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+	var request commandRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		os.Exit(2)
+	}
+	switch mode {
+	case "fail":
+		fmt.Fprint(os.Stdout, `{"error":"key locked"}`)
+		return
+	case "sleep":
+		time.Sleep(10 * time.Second)
+		return
+	}
+	signer, _ := NewSecretSigner(helperSecret)
+	response := map[string]string{}
+	switch request.Method {
+	case "public_key":
+		public, _ := signer.PublicKey()
+		response["public_key"] = B2H(public)
+	case "sign":
+		hash, _ := hex.DecodeString(request.Hash)
+		msg, _ := hex.DecodeString(request.Message)
+		sig, _ := signer.Sign(hash, msg)
+		response["signature"] = B2H(sig)
+	}
+	json.NewEncoder(os.Stdout).Encode(response)
+}
+
+func helperSigner(mode string) *CommandSigner {
+	os.Setenv("SIGNER_HELPER", mode)
+	return NewCommandSigner(os.Args[0], "-test.run=TestHelperSigner")
+}
+
+func TestCommandSigner(t *testing.T) {
+	defer os.Unsetenv("SIGNER_HELPER")
+	signer := helperSigner("sign")
+	expected, err := NewSecretSigner(helperSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := signer.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPublic, _ := expected.PublicKey()
+	if !bytes.Equal(public, expectedPublic) {
+		t.Fatalf("expected public key %X, got %X", expectedPublic, public)
+	}
+
+	msg := []byte("hello chainsql")
+	sig, err := signer.Sign(Sha512Half(msg), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(public, Sha512Half(msg), msg, sig); err != nil || !ok {
+		t.Fatalf("expected the signature to be valid:%v", err)
+	}
+
+	if _, err := helperSigner("fail").Sign(Sha512Half(msg), msg); err == nil || err.Error() != "key locked" {
+		t.Fatalf("expected the error of the signer, got %v", err)
+	}
+	slow := helperSigner("sleep")
+	slow.Timeout = 200 * time.Millisecond
+	start := time.Now()
+	if _, err := slow.PublicKey(); err == nil || time.Since(start) > 5*time.Second {
+		t.Fatalf("expected the signer to time out, got %v", err)
+	}
+
+	if _, err := NewSigner(42); err == nil {
+		t.Fatal("expected an unsupported credential to fail")
+	}
+	if s, err := NewSigner(signer); err != nil || s != Signer(signer) {
+		t.Fatalf("expected the signer itself, got %v:%v", s, err)
+	}
+}
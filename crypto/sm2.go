@@ -0,0 +1,107 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// SM2PublicKeyPrefix is the first byte of the 65 bytes SM2 public keys,
+// followed by the X and Y coordinates
+const SM2PublicKeyPrefix = 0x47
+
+const sm2PublicKeyLength = 65
+
+// sm2Key has no account families, the sequence is ignored
+type sm2Key struct {
+	priv *sm2.PrivateKey
+}
+
+type sm2Signature struct {
+	R, S *big.Int
+}
+
+// NewSM2Key derive the key from seed, if seed is nil, generate a random one
+func NewSM2Key(seed []byte) (*sm2Key, error) {
+	if seed == nil {
+		priv, err := sm2.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return &sm2Key{priv: priv}, nil
+	}
+	order := sm2.P256Sm2().Params().N
+	inc := big.NewInt(0).SetBytes(seed)
+	inc.Lsh(inc, 32)
+	for d := big.NewInt(0); ; inc.Add(inc, one) {
+		d.SetBytes(Sm3(inc.Bytes()))
+		if d.Cmp(zero) > 0 && d.Cmp(order) < 0 {
+			return NewSM2KeyFromPrivate(d.Bytes())
+		}
+	}
+}
+
+// NewSM2KeyFromPrivate create the key of the private scalar
+func NewSM2KeyFromPrivate(private []byte) (*sm2Key, error) {
+	curve := sm2.P256Sm2()
+	d := big.NewInt(0).SetBytes(private)
+	if d.Cmp(zero) <= 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, fmt.Errorf("Invalid SM2 private key")
+	}
+	priv := new(sm2.PrivateKey)
+	priv.Curve = curve
+	priv.D = d
+	priv.X, priv.Y = curve.ScalarBaseMult(d.Bytes())
+	return &sm2Key{priv: priv}, nil
+}
+
+func (k *sm2Key) Id(sequence *uint32) []byte {
+	return Sm3RipeMD160(k.Public(sequence))
+}
+
+func (k *sm2Key) Private(sequence *uint32) []byte {
+	private := make([]byte, 32)
+	b := k.priv.D.Bytes()
+	copy(private[32-len(b):], b)
+	return private
+}
+
+func (k *sm2Key) Public(sequence *uint32) []byte {
+	public := make([]byte, sm2PublicKeyLength)
+	public[0] = SM2PublicKeyPrefix
+	x, y := k.priv.X.Bytes(), k.priv.Y.Bytes()
+	copy(public[33-len(x):33], x)
+	copy(public[sm2PublicKeyLength-len(y):], y)
+	return public
+}
+
+// Returns DER encoded signature of msg hashed by SM3 with the default user id
+func signSM2(priv *sm2.PrivateKey, msg []byte) ([]byte, error) {
+	r, s, err := sm2.Sm2Sign(priv, msg, nil, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(sm2Signature{r, s})
+}
+
+func verifySM2(pubKey, signature, msg []byte) (bool, error) {
+	if len(pubKey) != sm2PublicKeyLength {
+		return false, fmt.Errorf("Wrong public key length: %d", len(pubKey))
+	}
+	var sig sm2Signature
+	if _, err := asn1.Unmarshal(signature, &sig); err != nil {
+		return false, err
+	}
+	pub := &sm2.PublicKey{
+		Curve: sm2.P256Sm2(),
+		X:     big.NewInt(0).SetBytes(pubKey[1:33]),
+		Y:     big.NewInt(0).SetBytes(pubKey[33:]),
+	}
+	if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+		return false, nil
+	}
+	return sm2.Sm2Verify(pub, msg, nil, sig.R, sig.S), nil
+}
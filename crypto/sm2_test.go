@@ -0,0 +1,115 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+)
+
+func TestSM2(t *testing.T) {
+	account, err := GenerateAccountKeys(WithAlgorithm(SoftGM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, keyType, err := NewKeyFromSecret(account.PrivateKey)
+	if err != nil || keyType != SoftGM {
+		t.Fatalf("unexpected key type %s:%v", keyType, err)
+	}
+	recreated, err := GenerateAccountKeys(WithSecret(account.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *recreated != *account {
+		t.Fatalf("expected %+v, got %+v", account, recreated)
+	}
+	public := key.Public(nil)
+	if len(public) != 65 || public[0] != SM2PublicKeyPrefix || B2H(public) != account.PublicKeyHex {
+		t.Fatalf("unexpected public key %X", public)
+	}
+	id, err := AccountId(key, nil)
+	if err != nil || id.String() != account.Address || !bytes.Equal(key.Id(nil), Sm3RipeMD160(public)) {
+		t.Fatalf("unexpected account %s:%v", id, err)
+	}
+
+	msg := []byte("hello chainsql")
+	sig, err := SignWithKey(key, nil, Sha512Half(msg), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(public, nil, msg, sig); err != nil || !ok {
+		t.Fatalf("expected the signature to be valid:%v", err)
+	}
+	if ok, _ := Verify(public, nil, []byte("hello"), sig); ok {
+		t.Fatal("expected the signature of another msg to be invalid")
+	}
+
+	seeded1, err := NewSM2Key([]byte("seed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seeded2, err := NewSM2Key([]byte("seed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(seeded1.Public(nil), seeded2.Public(nil)) {
+		t.Fatal("expected the key of a seed to be deterministic")
+	}
+
+	ecdsa, err := GenerateAccountKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, keyType, err := NewKeyFromSecret(ecdsa.PrivateKey); err != nil || keyType != ECDSA {
+		t.Fatalf("unexpected key type %s:%v", keyType, err)
+	}
+}
+
+// TestSM2KnownAnswer uses the sample key and signature of the SM2 standard
+// GM/T 0003.5 on the recommended curve, signed with the default user id
+func TestSM2KnownAnswer(t *testing.T) {
+	const (
+		// the private key 3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8
+		secret  = "p9XXtGmthhjVjnDSuA2ZMmPzPH1xcLWjQePXZ88NHVZFssqUr8v"
+		public  = "4709F9DF311E5421A150DD7D161E4BC5C672179FAD1833FC076BB08FF356F35020CCEA490CE26775A52DC6EA718CC1AA600AED05FBF35E084A6632F6072DA9AD13"
+		address = "zpQdiKPfNgWbtorRfUyvwSMuTy3nCdKXSE"
+	)
+	if sm3 := B2H(Sm3([]byte("abc"))); sm3 != "66C7F0F462EEEDD9D1F2D46BDC10E4E24167C4875CF2F7A2297DA02B8F4BA8E0" {
+		t.Fatalf("unexpected SM3 %s", sm3)
+	}
+	account, err := GenerateAccountKeys(WithSecret(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.PublicKeyHex != public || account.Address != address {
+		t.Fatalf("unexpected account %+v", account)
+	}
+
+	r, _ := big.NewInt(0).SetString("F5A03B0648D2C4630EEAC513E1BB81A15944DA3827D5B74143AC7EACEEE720B3", 16)
+	s, _ := big.NewInt(0).SetString("B1B6AA29DF212FD8763182BC0D421CA1BB9038FD1F7F42D4840B69C485BBC1AA", 16)
+	sig, err := asn1.Marshal(sm2Signature{r, s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, _ := H2B(public)
+	if ok, err := Verify(publicKey, nil, []byte("message digest"), sig); err != nil || !ok {
+		t.Fatalf("expected the sample signature to be valid:%v", err)
+	}
+}
+
+func TestSM4(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	for _, plain := range [][]byte{nil, []byte("0123456789abcdef"), []byte("chainsql")} {
+		encrypted, err := SM4Encrypt(key, plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := SM4Decrypt(key, encrypted)
+		if err != nil || !bytes.Equal(decrypted, plain) {
+			t.Fatalf("expected %q, got %q:%v", plain, decrypted, err)
+		}
+	}
+	if _, err := SM4Decrypt(key, make([]byte, 8)); err == nil {
+		t.Fatal("expected a short cipher text to fail")
+	}
+}
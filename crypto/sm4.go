@@ -0,0 +1,45 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/tjfoc/gmsm/sm4"
+)
+
+// SM4Encrypt encrypt plain with the 16 bytes key in CBC mode with PKCS#7 padding,
+// the random IV is put before the cipher text
+func SM4Encrypt(key, plain []byte) ([]byte, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padding := sm4.BlockSize - len(plain)%sm4.BlockSize
+	padded := append(append([]byte(nil), plain...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	out := make([]byte, sm4.BlockSize+len(padded))
+	if _, err := rand.Read(out[:sm4.BlockSize]); err != nil {
+		return nil, err
+	}
+	cipher.NewCBCEncrypter(block, out[:sm4.BlockSize]).CryptBlocks(out[sm4.BlockSize:], padded)
+	return out, nil
+}
+
+// SM4Decrypt decrypt the output of SM4Encrypt
+func SM4Decrypt(key, encrypted []byte) ([]byte, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted) < 2*sm4.BlockSize || len(encrypted)%sm4.BlockSize != 0 {
+		return nil, fmt.Errorf("Wrong cipher text length: %d", len(encrypted))
+	}
+	plain := make([]byte, len(encrypted)-sm4.BlockSize)
+	cipher.NewCBCDecrypter(block, encrypted[:sm4.BlockSize]).CryptBlocks(plain, encrypted[sm4.BlockSize:])
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > sm4.BlockSize || !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, fmt.Errorf("Wrong padding")
+	}
+	return plain[:len(plain)-padding], nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"fmt"
 
+	"github.com/tjfoc/gmsm/sm3"
 	"golang.org/x/crypto/ripemd160"
 )
 
@@ -42,6 +43,20 @@ func Sha256RipeMD160(b []byte) []byte {
 	return ripe.Sum(nil)
 }
 
+// Returns the SM3 digest of the input bytes
+func Sm3(b []byte) []byte {
+	hasher := sm3.New()
+	hasher.Write(b)
+	return hasher.Sum(nil)
+}
+
+// Sm3RipeMD160 is the account id of SM2 public keys
+func Sm3RipeMD160(b []byte) []byte {
+	ripe := ripemd160.New()
+	ripe.Write(Sm3(b))
+	return ripe.Sum(nil)
+}
+
 func H2B(s string) ([]byte, error) {
 	return hex.DecodeString(s)
 }
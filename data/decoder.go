@@ -272,6 +272,17 @@ func readObject(r Reader, v *reflect.Value) error {
 				var signerEntry SignerEntry
 				s := reflect.ValueOf(&signerEntry)
 				err := readObject(r, &s)
+				if v.Type() == reflect.TypeOf(SignerEntryObj{}) {
+					v.Set(reflect.ValueOf(SignerEntryObj{SignerEntry: signerEntry}))
+				} else {
+					v.Set(s.Elem())
+				}
+				return err
+			case "Signer":
+				var signer TxSigner
+				s := reflect.ValueOf(&signer)
+				inner := reflect.ValueOf(&signer.Signer)
+				err := readObject(r, &inner)
 				v.Set(s.Elem())
 				return err
 			case "Majority":
@@ -287,6 +298,27 @@ func readObject(r Reader, v *reflect.Value) error {
 				err := readObject(r, &inner)
 				v.Set(m.Elem())
 				return err
+			case "Table":
+				var table TableObj
+				t := reflect.ValueOf(&table)
+				inner := reflect.ValueOf(&table.Table)
+				err := readObject(r, &inner)
+				v.Set(t.Elem())
+				return err
+			case "Validator":
+				var node SchemaNode
+				n := reflect.ValueOf(&node)
+				inner := reflect.ValueOf(&node.Validator)
+				err := readObject(r, &inner)
+				v.Set(n.Elem())
+				return err
+			case "Peer":
+				var peer SchemaPeer
+				p := reflect.ValueOf(&peer)
+				inner := reflect.ValueOf(&peer.Peer)
+				err := readObject(r, &inner)
+				v.Set(p.Elem())
+				return err
 			default:
 				return fmt.Errorf("Unexpected object: %s for field: %s", v.Type(), name)
 			}
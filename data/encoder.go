@@ -102,6 +102,16 @@ func writeRaw(w io.Writer, value interface{}, ignoreSigningFields bool) error {
 func encode(w io.Writer, value interface{}, ignoreSigningFields bool) error {
 	v := reflect.Indirect(reflect.ValueOf(value))
 	fields := getFields(&v, 0)
+	if ignoreSigningFields {
+		// the children of a signing field like Signers are left out too
+		signing := fields[:0]
+		for _, f := range fields {
+			if !f.encoding.SigningField() {
+				signing = append(signing, f)
+			}
+		}
+		fields = signing
+	}
 	// fmt.Println(fields.String())
 	return fields.Each(func(e enc, v interface{}) error {
 		if ignoreSigningFields && e.SigningField() {
@@ -54,6 +54,13 @@ const (
 	// PaymentChannelClaim flags
 	TxRenew TransactionFlag = 0x00010000
 	TxClose TransactionFlag = 0x00020000
+
+	// TableListSet grant flags
+	TxTableSelect  TransactionFlag = 0x00010000
+	TxTableInsert  TransactionFlag = 0x00020000
+	TxTableUpdate  TransactionFlag = 0x00040000
+	TxTableDelete  TransactionFlag = 0x00080000
+	TxTableExecute TransactionFlag = 0x00100000
 )
 
 // Ledger entry flags
@@ -112,6 +119,13 @@ var txFlagNames = map[TransactionType][]struct {
 		{TxFillOrKill, "FillOrKill"},
 		{TxSell, "Sell"},
 	},
+	TABLE_LIST_SET: {
+		{TxTableSelect, "Select"},
+		{TxTableInsert, "Insert"},
+		{TxTableUpdate, "Update"},
+		{TxTableDelete, "Delete"},
+		{TxTableExecute, "Execute"},
+	},
 	TRUST_SET: {
 		{TxSetAuth, "SetAuth"},
 		{TxSetNoRipple, "SetNoRipple"},
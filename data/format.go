@@ -14,14 +14,15 @@ type LedgerNamespace uint16
 
 const (
 	// Hash Prefixes
-	HP_TRANSACTION_ID   HashPrefix = 0x54584E00 // 'TXN' transaction
-	HP_TRANSACTION_NODE HashPrefix = 0x534E4400 // 'SND' transaction plus metadata (probably should have been TND!)
-	HP_LEAF_NODE        HashPrefix = 0x4D4C4E00 // 'MLN' account state
-	HP_INNER_NODE       HashPrefix = 0x4D494E00 // 'MIN' inner node in tree
-	HP_LEDGER_MASTER    HashPrefix = 0x4C575200 // 'LWR' ledger master data for signing (probably should have been LGR!)
-	HP_TRANSACTION_SIGN HashPrefix = 0x53545800 // 'STX' inner transaction to sign
-	HP_VALIDATION       HashPrefix = 0x56414C00 // 'VAL' validation for signing
-	HP_PROPOSAL         HashPrefix = 0x50525000 // 'PRP' proposal for signing
+	HP_TRANSACTION_ID        HashPrefix = 0x54584E00 // 'TXN' transaction
+	HP_TRANSACTION_NODE      HashPrefix = 0x534E4400 // 'SND' transaction plus metadata (probably should have been TND!)
+	HP_LEAF_NODE             HashPrefix = 0x4D4C4E00 // 'MLN' account state
+	HP_INNER_NODE            HashPrefix = 0x4D494E00 // 'MIN' inner node in tree
+	HP_LEDGER_MASTER         HashPrefix = 0x4C575200 // 'LWR' ledger master data for signing (probably should have been LGR!)
+	HP_TRANSACTION_SIGN      HashPrefix = 0x53545800 // 'STX' inner transaction to sign
+	HP_TRANSACTION_MULTISIGN HashPrefix = 0x534D5400 // 'SMT' inner transaction to multi-sign
+	HP_VALIDATION            HashPrefix = 0x56414C00 // 'VAL' validation for signing
+	HP_PROPOSAL              HashPrefix = 0x50525000 // 'PRP' proposal for signing
 
 	// Node Types
 	NT_UNKNOWN          NodeType = 0
@@ -134,6 +135,7 @@ var encodings = map[enc]string{
 	enc{ST_UINT32, 37}: "FinishAfter",
 	enc{ST_UINT32, 38}: "SignerListID",
 	enc{ST_UINT32, 39}: "SettleDelay",
+	enc{ST_UINT32, 50}: "NeedVerify",
 	// 64-bit unsigned integers (common)
 	enc{ST_UINT64, 1}:  "IndexNext",
 	enc{ST_UINT64, 2}:  "IndexPrevious",
@@ -166,6 +168,8 @@ var encodings = map[enc]string{
 	enc{ST_HASH256, 21}: "Digest",
 	enc{ST_HASH256, 22}: "Channel",
 	enc{ST_HASH256, 24}: "CheckID",
+	enc{ST_HASH256, 50}: "SchemaID",
+	enc{ST_HASH256, 51}: "AnchorLedgerHash",
 	// currency amount (common)
 	enc{ST_AMOUNT, 1}:  "Amount",
 	enc{ST_AMOUNT, 2}:  "Balance",
@@ -202,16 +206,23 @@ var encodings = map[enc]string{
 	enc{ST_VL, 18}: "MasterSignature",
 	enc{ST_VL, 51}: "TableName",
 	enc{ST_VL, 52}: "Raw",
+	enc{ST_VL, 53}: "TableNewName",
 	enc{ST_VL, 54}: "AutoFillField",
+	enc{ST_VL, 55}: "Token",
+	enc{ST_VL, 56}: "Statements",
+	enc{ST_VL, 60}: "SchemaName",
+	enc{ST_VL, 61}: "Endpoint",
 	// account
-	enc{ST_ACCOUNT, 1}: "Account",
-	enc{ST_ACCOUNT, 2}: "Owner",
-	enc{ST_ACCOUNT, 3}: "Destination",
-	enc{ST_ACCOUNT, 4}: "Issuer",
-	enc{ST_ACCOUNT, 5}: "Authorize",
-	enc{ST_ACCOUNT, 6}: "Unauthorize",
-	enc{ST_ACCOUNT, 7}: "Target",
-	enc{ST_ACCOUNT, 8}: "RegularKey",
+	enc{ST_ACCOUNT, 1}:  "Account",
+	enc{ST_ACCOUNT, 2}:  "Owner",
+	enc{ST_ACCOUNT, 3}:  "Destination",
+	enc{ST_ACCOUNT, 4}:  "Issuer",
+	enc{ST_ACCOUNT, 5}:  "Authorize",
+	enc{ST_ACCOUNT, 6}:  "Unauthorize",
+	enc{ST_ACCOUNT, 7}:  "Target",
+	enc{ST_ACCOUNT, 8}:  "RegularKey",
+	enc{ST_ACCOUNT, 9}:  "User",
+	enc{ST_ACCOUNT, 10}: "SchemaAdmin",
 
 	// inner object
 	enc{ST_OBJECT, 1}:  "EndOfObject",
@@ -229,6 +240,8 @@ var encodings = map[enc]string{
 	enc{ST_OBJECT, 16}: "Signer",
 	enc{ST_OBJECT, 18}: "Majority",
 	enc{ST_OBJECT, 50}: "Table",
+	enc{ST_OBJECT, 51}: "Validator",
+	enc{ST_OBJECT, 52}: "Peer",
 	// array of objects
 	enc{ST_ARRAY, 1}:  "EndOfArray",
 	enc{ST_ARRAY, 2}:  "SigningAccounts",
@@ -240,6 +253,8 @@ var encodings = map[enc]string{
 	enc{ST_ARRAY, 8}:  "AffectedNodes",
 	enc{ST_ARRAY, 9}:  "Memos",
 	enc{ST_ARRAY, 51}: "Tables",
+	enc{ST_ARRAY, 52}: "Validators",
+	enc{ST_ARRAY, 53}: "PeerList",
 	// array of objects (uncommon)
 	enc{ST_ARRAY, 16}: "Majorities",
 	// 8-bit unsigned integers (common)
@@ -248,6 +263,7 @@ var encodings = map[enc]string{
 	enc{ST_UINT8, 3}: "TransactionResult",
 	// 8-bit unsigned integers (uncommon)
 	enc{ST_UINT8, 16}: "TickSize",
+	enc{ST_UINT8, 50}: "SchemaStrategy",
 	// 160-bit (common)
 	enc{ST_HASH160, 1}:  "TakerPaysCurrency",
 	enc{ST_HASH160, 2}:  "TakerPaysIssuer",
@@ -270,7 +286,8 @@ func init() {
 	signingFields = make(map[enc]struct{})
 	for e, name := range encodings {
 		reverseEncodings[name] = e
-		if strings.Contains(name, "Signature") {
+		// the signatures of the multi-signers are not signed either
+		if strings.Contains(name, "Signature") || name == "Signers" {
 			signingFields[e] = struct{}{}
 		}
 	}
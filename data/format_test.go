@@ -0,0 +1,51 @@
+package data
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// the field codes of the schema transactions, see SField.cpp of chainsqld
+var schemaFieldTests = []struct {
+	Name   string
+	Enc    enc
+	Header string
+}{
+	{"SchemaID", enc{ST_HASH256, 50}, "5032"},
+	{"AnchorLedgerHash", enc{ST_HASH256, 51}, "5033"},
+	{"SchemaName", enc{ST_VL, 60}, "703C"},
+	{"Endpoint", enc{ST_VL, 61}, "703D"},
+	{"SchemaAdmin", enc{ST_ACCOUNT, 10}, "8A"},
+	{"Validator", enc{ST_OBJECT, 51}, "E033"},
+	{"Peer", enc{ST_OBJECT, 52}, "E034"},
+	{"Validators", enc{ST_ARRAY, 52}, "F034"},
+	{"PeerList", enc{ST_ARRAY, 53}, "F035"},
+	{"SchemaStrategy", enc{ST_UINT8, 50}, "001032"},
+}
+
+func TestSchemaFieldCodes(t *testing.T) {
+	for _, test := range schemaFieldTests {
+		if e, ok := reverseEncodings[test.Name]; !ok || e != test.Enc {
+			t.Fatalf("expected %s to be %v, got %v", test.Name, test.Enc, e)
+		}
+		if test.Enc.SigningField() {
+			t.Fatalf("expected %s to be signed", test.Name)
+		}
+		var b bytes.Buffer
+		if err := writeEncoding(&b, test.Enc); err != nil {
+			t.Fatal(err)
+		}
+		if header := strings.ToUpper(hex.EncodeToString(b.Bytes())); header != test.Header {
+			t.Fatalf("expected the header of %s to be %s, got %s", test.Name, test.Header, header)
+		}
+		e, err := readEncoding(bytes.NewReader(b.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *e != test.Enc || encodings[*e] != test.Name {
+			t.Fatalf("expected %s read back, got %v", test.Name, e)
+		}
+	}
+}
@@ -9,30 +9,20 @@ import (
 	"github.com/ChainSQL/go-chainsql-api/crypto"
 )
 
-type KeyType int
+type KeyType = crypto.KeyType
 
 const (
-	ECDSA   KeyType = 0
-	Ed25519 KeyType = 1
+	ECDSA   = crypto.ECDSA
+	Ed25519 = crypto.Ed25519
+	SoftGM  = crypto.SoftGM
 )
 
-func (keyType KeyType) String() string {
-	switch keyType {
-	case ECDSA:
-		return "ECDSA"
-	case Ed25519:
-		return "Ed25519"
-	default:
-		return "unknown key type"
-	}
-}
-
 type Hash128 [16]byte
 type Hash160 [20]byte
 type Hash256 [32]byte
 type Vector256 []Hash256
 type VariableLength []byte
-type PublicKey [33]byte
+type PublicKey [65]byte // 33 bytes but 65 for SM2
 type Account [20]byte
 type RegularKey [20]byte
 type Seed [16]byte
@@ -160,7 +150,7 @@ func (v *VariableLength) Bytes() []byte {
 }
 
 func (p PublicKey) NodePublicKey() string {
-	hash, err := crypto.NewNodePublicKey(p[:])
+	hash, err := crypto.NewNodePublicKey(p.Bytes())
 	if err != nil {
 		return "Bad node public key"
 	}
@@ -177,10 +167,26 @@ func (p PublicKey) IsZero() bool {
 }
 
 func (p *PublicKey) Bytes() []byte {
-	if p != nil {
+	if p == nil {
+		return []byte(nil)
+	}
+	if p[0] == crypto.SM2PublicKeyPrefix {
 		return p[:]
 	}
-	return []byte(nil)
+	return p[:33]
+}
+
+// NewPublicKey expects 33 bytes, or 65 bytes for SM2
+func NewPublicKey(b []byte) (*PublicKey, error) {
+	var p PublicKey
+	switch {
+	case len(b) == 33 && b[0] != crypto.SM2PublicKeyPrefix:
+	case len(b) == 65 && b[0] == crypto.SM2PublicKeyPrefix:
+	default:
+		return nil, fmt.Errorf("PublicKey: wrong length %d", len(b))
+	}
+	copy(p[:], b)
+	return &p, nil
 }
 
 // Expects address in base58 form
@@ -305,6 +311,10 @@ func (s *Seed) Key(keyType KeyType) crypto.Key {
 		key, err = crypto.NewEd25519Key(s[:])
 	case ECDSA:
 		key, err = crypto.NewECDSAKey(s[:])
+	case SoftGM:
+		key, err = crypto.NewSM2Key(s[:])
+	default:
+		err = fmt.Errorf("unknown key type %d", keyType)
 	}
 	if err != nil {
 		panic(fmt.Sprintf("bad seed: %v", err))
@@ -318,11 +328,9 @@ func (s *Seed) AccountId(keyType KeyType, sequence *uint32) Account {
 	return account
 }
 
+// KeyFromSecret create the key of the family seed of ECDSA accounts,
+// or the private key of SoftGM accounts
 func KeyFromSecret(secret string) (crypto.Key, error) {
-	seed, err := NewSeedFromAddress(secret)
-	if err != nil {
-		return nil, err
-	}
-
-	return seed.Key(ECDSA), nil
+	key, _, err := crypto.NewKeyFromSecret(secret)
+	return key, err
 }
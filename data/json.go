@@ -497,13 +497,25 @@ func (p PublicKey) MarshalText() ([]byte, error) {
 	if p.IsZero() {
 		return []byte{}, nil
 	}
-	return b2h(p[:]), nil
+	return b2h(p.Bytes()), nil
 }
 
 // Expects public key hex
 func (p *PublicKey) UnmarshalText(b []byte) error {
-	_, err := hex.Decode(p[:], b)
-	return err
+	if len(b) == 0 {
+		*p = zeroPublicKey
+		return nil
+	}
+	key, err := hex.DecodeString(string(b))
+	if err != nil {
+		return err
+	}
+	pub, err := NewPublicKey(key)
+	if err != nil {
+		return err
+	}
+	*p = *pub
+	return nil
 }
 
 // A uint64 which gets represented as a hex string in json
@@ -517,7 +529,3 @@ func (h *Uint64Hex) UnmarshalText(b []byte) error {
 	_, err := fmt.Sscanf(string(b), "%X", h)
 	return err
 }
-
-func (keyType KeyType) MarshalText() ([]byte, error) {
-	return []byte(keyType.String()), nil
-}
@@ -33,7 +33,7 @@ func (p Proposal) SuppressionId() (Hash256, error) {
 		p.PreviousLedger,
 		p.Sequence,
 		p.CloseTime.Uint32(),
-		p.PublicKey,
+		p.PublicKey.Bytes(),
 		p.Signature,
 	})
 }
@@ -337,6 +337,37 @@ func (r TransactionResult) Queued() bool {
 	return r == terQUEUED
 }
 
+// Claimed reports a tec result, the tx is applied to claim the fee only
+func (r TransactionResult) Claimed() bool {
+	return r >= 100
+}
+
+// SequenceMismatch reports the results caused by a wrong Sequence or LastLedgerSequence
+func (r TransactionResult) SequenceMismatch() bool {
+	return r == tefPAST_SEQ || r == terPRE_SEQ || r == tefMAX_LEDGER
+}
+
+// InsufficientFee reports the results rejected for the fee, a higher fee may succeed
+func (r TransactionResult) InsufficientFee() bool {
+	return r == telINSUF_FEE_P || r == telCAN_NOT_QUEUE_FEE
+}
+
+// Retry reports the results the same tx may succeed with later
+func (r TransactionResult) Retry() bool {
+	return r == terPRE_SEQ || r == terRETRY
+}
+
+// PastSequence reports the tx sequence has already been used
+func (r TransactionResult) PastSequence() bool {
+	return r == tefPAST_SEQ
+}
+
+// ParseTransactionResult looks up a result by its token like "tesSUCCESS"
+func ParseTransactionResult(token string) (TransactionResult, bool) {
+	result, ok := reverseResults[token]
+	return result, ok
+}
+
 func (r TransactionResult) Symbol() string {
 	switch r {
 	case tesSUCCESS, tecCLAIM:
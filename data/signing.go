@@ -1,15 +1,33 @@
 package data
 
-import "github.com/ChainSQL/go-chainsql-api/crypto"
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+)
 
 func Sign(s Signer, key crypto.Key, sequence *uint32) error {
+	return SignWith(s, crypto.NewKeySigner(key, sequence))
+}
+
+// SignWith is the same as Sign but signed by signer, which may keep the key out of the process
+func SignWith(s Signer, signer crypto.Signer) error {
 	s.InitialiseForSigning()
-	copy(s.GetPublicKey().Bytes(), key.Public(sequence))
+	key, err := signer.PublicKey()
+	if err != nil {
+		return err
+	}
+	public, err := NewPublicKey(key)
+	if err != nil {
+		return err
+	}
+	*s.GetPublicKey() = *public
 	hash, msg, err := SigningHash(s)
 	if err != nil {
 		return err
 	}
-	sig, err := crypto.Sign(key.Private(sequence), hash.Bytes(), append(s.SigningPrefix().Bytes(), msg...))
+	sig, err := signer.Sign(hash.Bytes(), append(s.SigningPrefix().Bytes(), msg...))
 	if err != nil {
 		return err
 	}
@@ -27,5 +45,88 @@ func CheckSignature(s Signer) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	msg = append(s.SigningPrefix().Bytes(), msg...)
 	return crypto.Verify(s.GetPublicKey().Bytes(), hash.Bytes(), msg, s.GetSignature().Bytes())
 }
+
+// multiSigningData is the data a multi-signer signs, the tx without
+// signing fields between HP_TRANSACTION_MULTISIGN and the signer account
+func multiSigningData(s Transaction, account Account) (Hash256, []byte, error) {
+	_, msg, err := raw(s, HP_TRANSACTION_MULTISIGN, true)
+	if err != nil {
+		return zero256, nil, err
+	}
+	data := append(HP_TRANSACTION_MULTISIGN.Bytes(), msg...)
+	data = append(data, account.Bytes()...)
+	var hash Hash256
+	copy(hash[:], crypto.Sha512Half(data))
+	return hash, data, nil
+}
+
+// MultiSign sign s as the multi-signer account, the signature is
+// returned instead of filling TxnSignature, which must be empty in s
+func MultiSign(s Transaction, key crypto.Key, sequence *uint32, account Account) (*TxSigner, error) {
+	return MultiSignWith(s, crypto.NewKeySigner(key, sequence), account)
+}
+
+// MultiSignWith is the same as MultiSign but signed by signer
+func MultiSignWith(s Transaction, signer crypto.Signer, account Account) (*TxSigner, error) {
+	base := s.GetBase()
+	base.SigningPubKey = new(PublicKey)
+	base.TxnSignature = nil
+	hash, data, err := multiSigningData(s, account)
+	if err != nil {
+		return nil, err
+	}
+	key, err := signer.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	public, err := NewPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer.Sign(hash.Bytes(), data)
+	if err != nil {
+		return nil, err
+	}
+	txSigner := &TxSigner{}
+	txSigner.Signer.Account = account
+	txSigner.Signer.SigningPubKey = *public
+	txSigner.Signer.TxnSignature = VariableLength(sig)
+	return txSigner, nil
+}
+
+// CheckMultiSignature verify the signature of a multi-signer of s
+func CheckMultiSignature(s Transaction, signer *TxSigner) (bool, error) {
+	hash, data, err := multiSigningData(s, signer.Signer.Account)
+	if err != nil {
+		return false, err
+	}
+	return crypto.Verify(signer.Signer.SigningPubKey.Bytes(), hash.Bytes(), data, signer.Signer.TxnSignature.Bytes())
+}
+
+// SetSigners put the signatures of the multi-signers into s sorted by account
+// as required by the nodes, and compute the hash of s
+func SetSigners(s Transaction, signers []TxSigner) error {
+	sorted := make([]TxSigner, len(signers))
+	copy(sorted, signers)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Signer.Account.Less(sorted[j].Signer.Account)
+	})
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Signer.Account.Equals(sorted[i-1].Signer.Account) {
+			return fmt.Errorf("duplicate signer %s", sorted[i].Signer.Account)
+		}
+	}
+	base := s.GetBase()
+	base.SigningPubKey = new(PublicKey)
+	base.TxnSignature = nil
+	base.Signers = sorted
+	hash, _, err := Raw(s)
+	if err != nil {
+		return err
+	}
+	copy(s.GetHash().Bytes(), hash.Bytes())
+	return nil
+}
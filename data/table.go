@@ -1,10 +1,16 @@
 package data
 
-import "encoding/hex"
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+)
 
 type TableName struct {
-	TableName VariableLength `json:"TableName,omitempty"`
-	NameInDB  Hash160        `json:"NameInDB,omitempty"`
+	TableName    VariableLength `json:"TableName,omitempty"`
+	NameInDB     Hash160        `json:"NameInDB,omitempty"`
+	TableNewName VariableLength `json:"TableNewName,omitempty"`
 }
 
 // TableFields defines the table struct
@@ -40,6 +46,21 @@ func FormatTables(name string, nameInDB string) []TableObj {
 	}
 }
 
+// FormatTablesForRename create the Tables json array for a rename TableListSet
+func FormatTablesForRename(name string, nameInDB string, newName string) []TableObj {
+	tables := FormatTables(name, nameInDB)
+	tables[0].Table.TableNewName = VariableLength(newName)
+	return tables
+}
+
+// GenerateNameInDB generate the NameInDB for a table to create,
+// the same way as chainsqld does in t_prepare
+func GenerateNameInDB(ledgerSeq uint32, address string, name string) string {
+	str := fmt.Sprintf("%d%s%s", ledgerSeq, address, name)
+	hash := crypto.Sha512Half([]byte(str))
+	return fmt.Sprintf("%X", hash[:20])
+}
+
 func FormatTablesForGet(name string, nameInDB string) []TableObjForGet {
 	return []TableObjForGet{
 		{
@@ -13,6 +13,7 @@ type TxBase struct {
 	Memos              Memos           `json:",omitempty"`
 	PreviousTxnID      *Hash256        `json:",omitempty"`
 	LastLedgerSequence *uint32         `json:",omitempty"`
+	Signers            []TxSigner      `json:",omitempty"`
 	Hash               Hash256         `json:"hash"`
 }
 
@@ -174,8 +175,22 @@ type TicketCancel struct {
 
 type SignerListSet struct {
 	TxBase
-	SignerQuorum  uint32        `json:",omitempty"`
-	SignerEntries []SignerEntry `json:",omitempty"`
+	SignerQuorum  uint32           `json:",omitempty"`
+	SignerEntries []SignerEntryObj `json:",omitempty"`
+}
+
+// SignerEntryObj is an element of SignerEntries in SignerListSet
+type SignerEntryObj struct {
+	SignerEntry SignerEntry
+}
+
+// TxSigner is an element of Signers in a multi-signed transaction
+type TxSigner struct {
+	Signer struct {
+		Account       Account
+		SigningPubKey PublicKey
+		TxnSignature  VariableLength
+	}
 }
 
 type TableListSet struct {
@@ -183,6 +198,8 @@ type TableListSet struct {
 	Tables []TableObj
 	Raw    *VariableLength `json:"Raw,omitempty"`
 	OpType uint16
+	User   *Account        `json:",omitempty"`
+	Token  *VariableLength `json:",omitempty"`
 }
 
 type SQLStatement struct {
@@ -194,6 +211,54 @@ type SQLStatement struct {
 	// AutoFillField *VariableLength `json:"AutoFillField,omitempty"`
 }
 
+type SQLTransaction struct {
+	TxBase
+	Statements VariableLength
+	NeedVerify uint32
+}
+
+// Statement is an element of the json-array Statements in SQLTransaction
+type Statement struct {
+	TransactionType TransactionType
+	Account         Account
+	Owner           Account
+	Tables          []TableObj
+	OpType          uint16
+	Raw             *VariableLength `json:"Raw,omitempty"`
+}
+
+type SchemaCreate struct {
+	TxBase
+	SchemaName       VariableLength
+	SchemaStrategy   uint8
+	SchemaAdmin      *Account     `json:",omitempty"`
+	AnchorLedgerHash *Hash256     `json:",omitempty"`
+	Validators       []SchemaNode `json:",omitempty"`
+	PeerList         []SchemaPeer `json:",omitempty"`
+}
+
+type SchemaModify struct {
+	TxBase
+	OpType     uint16
+	SchemaID   Hash256
+	Validators []SchemaNode `json:",omitempty"`
+	PeerList   []SchemaPeer `json:",omitempty"`
+}
+
+// SchemaNode is a validator of a schema
+type SchemaNode struct {
+	Validator struct {
+		PublicKey VariableLength
+	}
+}
+
+// SchemaPeer is a peer endpoint of a schema
+type SchemaPeer struct {
+	Peer struct {
+		Endpoint VariableLength
+	}
+}
+
 func (t *TxBase) GetBase() *TxBase                    { return t }
 func (t *TxBase) GetType() string                     { return txNames[t.TransactionType] }
 func (t *TxBase) GetTransactionType() TransactionType { return t.TransactionType }
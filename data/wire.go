@@ -140,7 +140,17 @@ func (a *Account) Marshal(w io.Writer) error {
 }
 
 func (k *PublicKey) Unmarshal(r Reader) error {
-	return readExpectedLength(r, k[:], "PublicKey")
+	length, err := readVariableLength(r)
+	switch {
+	case err != nil:
+		return fmt.Errorf("PublicKey: %s", err.Error())
+	case length == 0:
+		return nil
+	case length == 33 || length == 65:
+		return unmarshalSlice(k[:length], r, "PublicKey")
+	default:
+		return fmt.Errorf("PublicKey: wrong length %d expected: 33 or 65", length)
+	}
 }
 
 func (k *PublicKey) Marshal(w io.Writer) error {
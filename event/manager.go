@@ -12,34 +12,59 @@ import (
 // Manager manages the subscription
 type Manager struct {
 	txCache          map[string]export.Callback
-	tableCache       map[string]export.Callback
-	ledgerCloseCache []export.Callback
+	tableCache       map[tableKey]*TableSubscription
+	ledgerCloseCache []*ledgerSubscription
+	ledgerSubID      int64
 	muxTx            *sync.Mutex
 	muxTable         *sync.Mutex
+	muxLedger        *sync.Mutex
+}
+
+// tableKey identifies a subscribed table by name and owner
+type tableKey struct {
+	name  string
+	owner string
+}
+
+type ledgerSubscription struct {
+	id       int64
+	callback export.Callback
 }
 
 // NewEventManager is constructor for EventManager
 func NewEventManager() *Manager {
 	return &Manager{
 		txCache:          make(map[string]export.Callback),
-		tableCache:       make(map[string]export.Callback),
-		ledgerCloseCache: make([]export.Callback, 0, 10),
+		tableCache:       make(map[tableKey]*TableSubscription),
+		ledgerCloseCache: make([]*ledgerSubscription, 0, 10),
 		muxTx:            new(sync.Mutex),
 		muxTable:         new(sync.Mutex),
+		muxLedger:        new(sync.Mutex),
 	}
 }
 
+// TableSubscription is a subscribed table
+type TableSubscription struct {
+	Name     string
+	Owner    string
+	callback export.Callback
+}
+
 // SubscribeTable subscribe a table and set a callback function
 func (e *Manager) SubscribeTable(name string, owner string, callback export.Callback) {
 	e.muxTable.Lock()
-	e.tableCache[name+owner] = callback
+	e.tableCache[tableKey{name, owner}] = &TableSubscription{
+		Name:     name,
+		Owner:    owner,
+		callback: callback,
+	}
 	e.muxTable.Unlock()
 }
 
 // UnSubscribeTable cancel the subscription
 func (e *Manager) UnSubscribeTable(name string, owner string) {
 	e.muxTable.Lock()
-	delete(e.tableCache, name+owner)
+	delete(e.tableCache, tableKey{name, owner})
 	e.muxTable.Unlock()
 }
 
@@ -57,15 +82,39 @@ func (e *Manager) UnSubscribeTx(hash string) {
 	e.muxTx.Unlock()
 }
 
-// SubscribeLedger subscribe ledgerClosed
-func (e *Manager) SubscribeLedger(callback export.Callback) {
-	e.ledgerCloseCache = append(e.ledgerCloseCache, callback)
+// SubscribeLedger subscribe ledgerClosed,
+// the returned id is used to cancel the subscription by UnSubscribeLedger
+func (e *Manager) SubscribeLedger(callback export.Callback) int64 {
+	e.muxLedger.Lock()
+	defer e.muxLedger.Unlock()
+	e.ledgerSubID++
+	e.ledgerCloseCache = append(e.ledgerCloseCache, &ledgerSubscription{
+		id:       e.ledgerSubID,
+		callback: callback,
+	})
+	return e.ledgerSubID
+}
+
+// UnSubscribeLedger cancel the ledgerClosed subscription with id
+func (e *Manager) UnSubscribeLedger(id int64) {
+	e.muxLedger.Lock()
+	defer e.muxLedger.Unlock()
+	for i, sub := range e.ledgerCloseCache {
+		if sub.id == id {
+			e.ledgerCloseCache = append(e.ledgerCloseCache[:i], e.ledgerCloseCache[i+1:]...)
+			return
+		}
+	}
 }
 
 // OnLedgerClosed trigger the callback
 func (e *Manager) OnLedgerClosed(msg string) {
-	for i := 0; i < len(e.ledgerCloseCache); i++ {
-		e.ledgerCloseCache[i](msg)
+	e.muxLedger.Lock()
+	subs := make([]*ledgerSubscription, len(e.ledgerCloseCache))
+	copy(subs, e.ledgerCloseCache)
+	e.muxLedger.Unlock()
+	for _, sub := range subs {
+		sub.callback(msg)
 	}
 }
 
@@ -105,7 +154,45 @@ func (e *Manager) OnSingleTransaction(msg string) {
 	}
 }
 
+// GetTxSubscriptions return the hashes of all the subscribed transactions
+func (e *Manager) GetTxSubscriptions() []string {
+	e.muxTx.Lock()
+	defer e.muxTx.Unlock()
+	hashes := make([]string, 0, len(e.txCache))
+	for hash := range e.txCache {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+// GetTableSubscriptions return all the subscribed tables
+func (e *Manager) GetTableSubscriptions() []TableSubscription {
+	e.muxTable.Lock()
+	defer e.muxTable.Unlock()
+	tables := make([]TableSubscription, 0, len(e.tableCache))
+	for _, sub := range e.tableCache {
+		tables = append(tables, *sub)
+	}
+	return tables
+}
+
 //OnTableMsg trigger the callback
 func (e *Manager) OnTableMsg(msg string) {
+	name, err := jsonparser.GetString([]byte(msg), "tablename")
+	if err != nil {
+		log.Printf("OnTableMsg error:%s\n", err)
+		return
+	}
+	owner, err := jsonparser.GetString([]byte(msg), "owner")
+	if err != nil {
+		log.Printf("OnTableMsg error:%s\n", err)
+		return
+	}
 
+	e.muxTable.Lock()
+	sub, ok := e.tableCache[tableKey{name, owner}]
+	e.muxTable.Unlock()
+	if ok {
+		sub.callback(msg)
+	}
 }
@@ -0,0 +1,57 @@
+package event
+
+import (
+	"fmt"
+	"testing"
+)
+
+func tableMsg(name string, owner string) string {
+	return fmt.Sprintf(`{"type":"table","tablename":"%s","owner":"%s","status":"validate_success"}`, name, owner)
+}
+
+func TestOnTableMsg(t *testing.T) {
+	e := NewEventManager()
+	received := make(map[string]int)
+	subscribe := func(name string, owner string) {
+		e.SubscribeTable(name, owner, func(msg string) {
+			received[name+"/"+owner]++
+		})
+	}
+	subscribe("t1", "owner1")
+	subscribe("t1", "owner2")
+	// the name and owner concatenated are the same as t1 of owner1
+	subscribe("t1o", "wner1")
+
+	e.OnTableMsg(tableMsg("t1", "owner1"))
+	e.OnTableMsg(tableMsg("t1", "owner2"))
+	e.OnTableMsg(tableMsg("t1", "owner2"))
+	e.OnTableMsg(tableMsg("t2", "owner1"))
+	e.OnTableMsg(`{"type":"table","tablename":"t1"}`)
+	if received["t1/owner1"] != 1 || received["t1/owner2"] != 2 || received["t1o/wner1"] != 0 || len(received) != 2 {
+		t.Fatalf("unexpected dispatch %v", received)
+	}
+	if tables := e.GetTableSubscriptions(); len(tables) != 3 {
+		t.Fatalf("expected 3 subscriptions, got %v", tables)
+	}
+
+	e.UnSubscribeTable("t1", "owner2")
+	e.OnTableMsg(tableMsg("t1", "owner2"))
+	e.OnTableMsg(tableMsg("t1", "owner1"))
+	if received["t1/owner1"] != 2 || received["t1/owner2"] != 2 {
+		t.Fatalf("unexpected dispatch after unsubscribing %v", received)
+	}
+	for _, table := range e.GetTableSubscriptions() {
+		if table.Name == "t1" && table.Owner == "owner2" {
+			t.Fatal("expected t1 of owner2 unsubscribed")
+		}
+	}
+
+	// subscribing again replaces the callback
+	e.SubscribeTable("t1", "owner1", func(msg string) {
+		received["replaced"]++
+	})
+	e.OnTableMsg(tableMsg("t1", "owner1"))
+	if received["t1/owner1"] != 2 || received["replaced"] != 1 {
+		t.Fatalf("unexpected dispatch after subscribing again %v", received)
+	}
+}
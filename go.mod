@@ -7,6 +7,7 @@ require (
 	github.com/buger/jsonparser v1.1.1
 	github.com/gorilla/websocket v1.4.2
 	github.com/kr/text v0.2.0 // indirect
-	golang.org/x/crypto v0.0.0-20200820211705-5c72a883971a
+	github.com/tjfoc/gmsm v1.4.1
+	golang.org/x/crypto v0.0.0-20201012173705-84dcc777aaee
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c
 )
@@ -0,0 +1,122 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	version   = 1
+	kdfScrypt = "scrypt"
+	cipherGCM = "aes-256-gcm"
+	keyLength = 32
+)
+
+// keyFile is the JSON of an account file
+type keyFile struct {
+	Version   int        `json:"version"`
+	Address   string     `json:"address"`
+	KeyType   string     `json:"keyType"`
+	PublicKey string     `json:"publicKey"`
+	Crypto    cryptoJSON `json:"crypto"`
+}
+
+type cryptoJSON struct {
+	KDF        string     `json:"kdf"`
+	KDFParams  scryptJSON `json:"kdfparams"`
+	Cipher     string     `json:"cipher"`
+	Nonce      string     `json:"nonce"`
+	CipherText string     `json:"ciphertext"`
+}
+
+type scryptJSON struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+// encryptSecret encrypt secret with the key derived from password,
+// the address is authenticated as the additional data of GCM
+func encryptSecret(secret, address, password string, scryptN, scryptP int) (*cryptoJSON, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	params := scryptJSON{
+		N:     scryptN,
+		R:     8,
+		P:     scryptP,
+		DKLen: keyLength,
+		Salt:  hex.EncodeToString(salt),
+	}
+	gcm, err := newGCM(password, &params)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &cryptoJSON{
+		KDF:        kdfScrypt,
+		KDFParams:  params,
+		Cipher:     cipherGCM,
+		Nonce:      hex.EncodeToString(nonce),
+		CipherText: hex.EncodeToString(gcm.Seal(nil, nonce, []byte(secret), []byte(address))),
+	}, nil
+}
+
+// decryptSecret return the secret of the file, ErrPassword if the password is wrong
+func decryptSecret(file *keyFile, password string) (string, error) {
+	if file.Version != version {
+		return "", fmt.Errorf("unsupported keystore version %d", file.Version)
+	}
+	c := &file.Crypto
+	if c.KDF != kdfScrypt || c.Cipher != cipherGCM {
+		return "", fmt.Errorf("unsupported kdf %s or cipher %s", c.KDF, c.Cipher)
+	}
+	gcm, err := newGCM(password, &c.KDFParams)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := hex.DecodeString(c.Nonce)
+	if err != nil || len(nonce) != gcm.NonceSize() {
+		return "", errors.New("invalid nonce")
+	}
+	cipherText, err := hex.DecodeString(c.CipherText)
+	if err != nil {
+		return "", err
+	}
+	secret, err := gcm.Open(nil, nonce, cipherText, []byte(file.Address))
+	if err != nil {
+		return "", ErrPassword
+	}
+	return string(secret), nil
+}
+
+func newGCM(password string, params *scryptJSON) (cipher.AEAD, error) {
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if params.DKLen != keyLength {
+		return nil, fmt.Errorf("unsupported dklen %d", params.DKLen)
+	}
+	key, err := scrypt.Key([]byte(password), salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
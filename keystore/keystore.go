@@ -0,0 +1,308 @@
+// Package keystore keeps the secrets of accounts in files encrypted by passwords.
+//
+// Each account is a file named <address>.json in the keystore directory:
+//
+//	{
+//	  "version": 1,
+//	  "address": "zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh",
+//	  "keyType": "ECDSA",
+//	  "publicKey": "<hex of the public key>",
+//	  "crypto": {
+//	    "kdf": "scrypt",
+//	    "kdfparams": {"n": 262144, "r": 8, "p": 1, "dklen": 32, "salt": "<hex>"},
+//	    "cipher": "aes-256-gcm",
+//	    "nonce": "<hex of 12 bytes>",
+//	    "ciphertext": "<hex>"
+//	  }
+//	}
+//
+// The key of AES-256-GCM is derived from the password by scrypt with kdfparams,
+// ciphertext is the secret of the account sealed with the address as the
+// additional data, so a file renamed to another account fails to open.
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+)
+
+const (
+	// StandardScryptN and StandardScryptP are the default scrypt parameters
+	StandardScryptN = 1 << 18
+	StandardScryptP = 1
+	// LightScryptN and LightScryptP use much less memory and time but are weaker
+	LightScryptN = 1 << 12
+	LightScryptP = 6
+)
+
+var (
+	// ErrPassword is returned when a file is opened with a wrong password
+	ErrPassword = errors.New("wrong keystore password")
+	// ErrLocked is returned when signing for an account not unlocked
+	ErrLocked = errors.New("keystore account locked")
+	// ErrNotFound is returned for an account not in the keystore
+	ErrNotFound = errors.New("keystore account not found")
+)
+
+// Entry is an account in the keystore
+type Entry struct {
+	Address   string
+	KeyType   string
+	PublicKey string
+	Path      string
+}
+
+// KeyStore is a directory of account files
+type KeyStore struct {
+	dir string
+	// ScryptN and ScryptP are used to encrypt the imported accounts,
+	// the files keep the parameters they are encrypted with
+	ScryptN  int
+	ScryptP  int
+	mutex    sync.Mutex
+	unlocked map[string]*unlocked
+}
+
+type unlocked struct {
+	signer crypto.Signer
+	timer  *time.Timer
+}
+
+// New open the keystore of dir, which is created if not exists
+func New(dir string) (*KeyStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &KeyStore{
+		dir:      dir,
+		ScryptN:  StandardScryptN,
+		ScryptP:  StandardScryptP,
+		unlocked: make(map[string]*unlocked),
+	}, nil
+}
+
+// Accounts list the accounts in the keystore sorted by address
+func (ks *KeyStore) Accounts() ([]Entry, error) {
+	files, err := ioutil.ReadDir(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, info := range files {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(ks.dir, info.Name())
+		file, err := readKeyFile(path)
+		if err != nil {
+			// not an account file
+			continue
+		}
+		entries = append(entries, file.entry(path))
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Address < entries[j].Address
+	})
+	return entries, nil
+}
+
+// Import encrypt secret by password into the keystore, an account
+// already in the keystore is not overwritten
+func (ks *KeyStore) Import(secret string, password string) (*Entry, error) {
+	account, err := crypto.GenerateAccountKeys(crypto.WithSecret(secret))
+	if err != nil {
+		return nil, err
+	}
+	_, keyType, err := crypto.NewKeyFromSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := encryptSecret(secret, account.Address, password, ks.ScryptN, ks.ScryptP)
+	if err != nil {
+		return nil, err
+	}
+	file := &keyFile{
+		Version:   version,
+		Address:   account.Address,
+		KeyType:   keyType.String(),
+		PublicKey: account.PublicKeyHex,
+		Crypto:    *encrypted,
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	path := ks.path(account.Address)
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("account %s already in the keystore", account.Address)
+		}
+		return nil, err
+	}
+	_, err = out.Write(data)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	entry := file.entry(path)
+	return &entry, nil
+}
+
+// NewAccount generate an account of keyType into the keystore
+func (ks *KeyStore) NewAccount(keyType crypto.KeyType, password string) (*Entry, error) {
+	account, err := crypto.GenerateAccountKeys(crypto.WithAlgorithm(keyType))
+	if err != nil {
+		return nil, err
+	}
+	return ks.Import(account.PrivateKey, password)
+}
+
+// Export decrypt the secret of address
+func (ks *KeyStore) Export(address string, password string) (string, error) {
+	file, err := readKeyFile(ks.path(address))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	if file.Address != address {
+		return "", fmt.Errorf("the file of %s is of %s", address, file.Address)
+	}
+	return decryptSecret(file, password)
+}
+
+// Unlock decrypt the key of address for signing by Signer, the account is
+// locked again after timeout, or until Lock if timeout is 0
+func (ks *KeyStore) Unlock(address string, password string, timeout time.Duration) error {
+	secret, err := ks.Export(address, password)
+	if err != nil {
+		return err
+	}
+	signer, err := crypto.NewSecretSigner(secret)
+	if err != nil {
+		return err
+	}
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	if previous, ok := ks.unlocked[address]; ok && previous.timer != nil {
+		previous.timer.Stop()
+	}
+	u := &unlocked{signer: signer}
+	if timeout > 0 {
+		u.timer = time.AfterFunc(timeout, func() {
+			ks.mutex.Lock()
+			defer ks.mutex.Unlock()
+			if ks.unlocked[address] == u {
+				delete(ks.unlocked, address)
+			}
+		})
+	}
+	ks.unlocked[address] = u
+	return nil
+}
+
+// Lock drop the key of address unlocked
+func (ks *KeyStore) Lock(address string) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	if u, ok := ks.unlocked[address]; ok {
+		if u.timer != nil {
+			u.timer.Stop()
+		}
+		delete(ks.unlocked, address)
+	}
+}
+
+// Signer return the signer of address for Chainsql.As,
+// it fails with ErrLocked while the account is locked
+func (ks *KeyStore) Signer(address string) crypto.Signer {
+	return &accountSigner{ks: ks, address: address}
+}
+
+func (ks *KeyStore) unlockedSigner(address string) (crypto.Signer, error) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	u, ok := ks.unlocked[address]
+	if !ok {
+		return nil, ErrLocked
+	}
+	return u.signer, nil
+}
+
+func (ks *KeyStore) path(address string) string {
+	return filepath.Join(ks.dir, address+".json")
+}
+
+func readKeyFile(path string) (*keyFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &keyFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, err
+	}
+	if file.Address == "" {
+		return nil, errors.New("no address in " + path)
+	}
+	return file, nil
+}
+
+func (f *keyFile) entry(path string) Entry {
+	return Entry{
+		Address:   f.Address,
+		KeyType:   f.KeyType,
+		PublicKey: f.PublicKey,
+		Path:      path,
+	}
+}
+
+// accountSigner signs with the key of an account while it is unlocked
+type accountSigner struct {
+	ks      *KeyStore
+	address string
+}
+
+func (a *accountSigner) PublicKey() ([]byte, error) {
+	signer, err := a.ks.unlockedSigner(a.address)
+	if err != nil {
+		return nil, err
+	}
+	return signer.PublicKey()
+}
+
+func (a *accountSigner) Sign(hash, msg []byte) ([]byte, error) {
+	signer, err := a.ks.unlockedSigner(a.address)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Sign(hash, msg)
+}
+
+// Decrypt decrypt the tokens of the confidential tables granted to the account
+func (a *accountSigner) Decrypt(data []byte) ([]byte, error) {
+	signer, err := a.ks.unlockedSigner(a.address)
+	if err != nil {
+		return nil, err
+	}
+	decrypter, ok := signer.(crypto.Decrypter)
+	if !ok {
+		return nil, fmt.Errorf("the key of %s can not decrypt", a.address)
+	}
+	return decrypter.Decrypt(data)
+}
@@ -0,0 +1,175 @@
+package keystore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+)
+
+const (
+	testAddress = "zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh"
+	testSecret  = "xnoPBzXtMeMyMHUVTgbuqAfg1SUTb"
+)
+
+func newTestKeyStore(t *testing.T) (*KeyStore, func()) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks.ScryptN, ks.ScryptP = LightScryptN, LightScryptP
+	return ks, func() { os.RemoveAll(dir) }
+}
+
+func TestImportExport(t *testing.T) {
+	ks, clean := newTestKeyStore(t)
+	defer clean()
+
+	entry, err := ks.Import(testSecret, "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Address != testAddress || entry.KeyType != "ECDSA" {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if _, err := ks.Import(testSecret, "pass"); err == nil {
+		t.Fatal("expected importing an account twice to fail")
+	}
+	gm, err := ks.NewAccount(crypto.SoftGM, "gm pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ks.Accounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 accounts, got %+v", entries)
+	}
+	for _, e := range entries {
+		if e.Address == gm.Address && e.KeyType != "softGMAlg" {
+			t.Fatalf("unexpected entry %+v", e)
+		}
+	}
+
+	secret, err := ks.Export(testAddress, "pass")
+	if err != nil || secret != testSecret {
+		t.Fatalf("expected the secret, got %s:%v", secret, err)
+	}
+	if _, err := ks.Export(testAddress, "wrong"); err != ErrPassword {
+		t.Fatalf("expected ErrPassword, got %v", err)
+	}
+	if _, err := ks.Export("zBonp9s7isAaDUPcfrFfYjNnhgeznoBHxF", "pass"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	// the secret is not in the file
+	data, err := ioutil.ReadFile(entry.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	if file.Version != 1 || file.Crypto.KDF != "scrypt" || file.Crypto.Cipher != "aes-256-gcm" || file.Crypto.KDFParams.N != LightScryptN {
+		t.Fatalf("unexpected file %s", data)
+	}
+
+	// a file copied to another account fails to open
+	other := filepath.Join(filepath.Dir(entry.Path), gm.Address+".json")
+	file.Address = gm.Address
+	data, _ = json.Marshal(file)
+	if err := ioutil.WriteFile(other, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Export(gm.Address, "pass"); err != ErrPassword {
+		t.Fatalf("expected the address to be authenticated, got %v", err)
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	ks, clean := newTestKeyStore(t)
+	defer clean()
+	if _, err := ks.Import(testSecret, "pass"); err != nil {
+		t.Fatal(err)
+	}
+
+	signer := ks.Signer(testAddress)
+	msg := []byte("hello")
+	if _, err := signer.Sign(crypto.Sha512Half(msg), msg); err != ErrLocked {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	if err := ks.Unlock(testAddress, "wrong", 0); err != ErrPassword {
+		t.Fatalf("expected ErrPassword, got %v", err)
+	}
+	if err := ks.Unlock(testAddress, "pass", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	public, err := signer.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signer.Sign(crypto.Sha512Half(msg), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := crypto.Verify(public, crypto.Sha512Half(msg), msg, sig); err != nil || !ok {
+		t.Fatalf("expected the signature to be valid:%v", err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if _, err := signer.Sign(crypto.Sha512Half(msg), msg); err != ErrLocked {
+		t.Fatalf("expected the session to expire, got %v", err)
+	}
+
+	if err := ks.Unlock(testAddress, "pass", 0); err != nil {
+		t.Fatal(err)
+	}
+	ks.Lock(testAddress)
+	if _, err := signer.PublicKey(); err != ErrLocked {
+		t.Fatalf("expected ErrLocked after Lock, got %v", err)
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	ks, clean := newTestKeyStore(t)
+	defer clean()
+	if _, err := ks.Import(testSecret, "pass"); err != nil {
+		t.Fatal(err)
+	}
+	signer := ks.Signer(testAddress)
+	decrypter, ok := signer.(crypto.Decrypter)
+	if !ok {
+		t.Fatalf("expected the keystore signer to decrypt")
+	}
+	msg := []byte("hello")
+	if _, err := decrypter.Decrypt(msg); err != ErrLocked {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+
+	if err := ks.Unlock(testAddress, "pass", 0); err != nil {
+		t.Fatal(err)
+	}
+	defer ks.Lock(testAddress)
+	public, err := signer.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := crypto.EncryptTo(public, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted, err := decrypter.Decrypt(encrypted); err != nil || string(decrypted) != string(msg) {
+		t.Fatalf("expected %s, got %s:%v", msg, decrypted, err)
+	}
+}
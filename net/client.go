@@ -1,10 +1,14 @@
 package net
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ChainSQL/go-chainsql-api/common"
@@ -16,122 +20,253 @@ import (
 	"github.com/buger/jsonparser"
 )
 
-//ReconnectInterval is the interval to reconnect when ws socket is disconnected
+//ReconnectInterval is the max interval in seconds to reconnect when ws socket is disconnected,
+//the interval starts from MinReconnectBackoff and doubles after each failure
 const ReconnectInterval = 10
 
 // Client is used to send and recv websocket msg
 type Client struct {
-	cmdIDs      int64
-	schemaID    string
-	wm          *WebsocketManager
-	sendMsgChan chan string
-	recvMsgChan chan string
-	requests    map[int64]*Request
-	mutex       *sync.RWMutex
-	Auth        *common.Auth
-	ServerInfo  *ServerInfo
-	Event       *event.Manager
-	inited      bool
+	cmdIDs        int64
+	schemaID      string
+	nodes         []*node
+	active        *node
+	poolMutex     *sync.RWMutex
+	failoverMutex *sync.Mutex
+	stopHealth    chan struct{}
+	requests      map[int64]*Request
+	mutex         *sync.RWMutex
+	Auth          *common.Auth
+	ServerInfo    *ServerInfo
+	Event         *event.Manager
+	Sequences     *SequenceManager
+	inited        bool
 }
 
 //NewClient is constructor
 func NewClient() *Client {
-	return &Client{
-		cmdIDs:     0,
-		requests:   make(map[int64]*Request),
-		mutex:      new(sync.RWMutex),
-		Auth:       &common.Auth{},
-		ServerInfo: NewServerInfo(),
-		Event:      event.NewEventManager(),
-		inited:     false,
-	}
+	c := &Client{
+		cmdIDs:        0,
+		poolMutex:     new(sync.RWMutex),
+		failoverMutex: new(sync.Mutex),
+		requests:      make(map[int64]*Request),
+		mutex:         new(sync.RWMutex),
+		Auth:          &common.Auth{},
+		ServerInfo:    NewServerInfo(),
+		Event:         event.NewEventManager(),
+		inited:        false,
+	}
+	c.Sequences = NewSequenceManager(c.fetchSequence)
+	return c
 }
 
-//Connect is used to create a websocket connection
-func (c *Client) Connect(url string) error {
-	if c.wm != nil {
-		return c.reConnect(url)
-	}
+//Connect is used to create websocket connections to one or several nodes,
+//requests are sent to the healthiest node and fail over to the others
+//when it is disconnected, times out or falls behind.
+//Calling Connect again replaces the nodes connected before
+func (c *Client) Connect(urls ...string) error {
+	return c.ConnectContext(context.Background(), urls...)
+}
 
-	c.wm = NewWsClientManager(url, ReconnectInterval)
-	err := c.wm.Start()
-	if err != nil {
-		return err
-	}
+//ConnectContext is the same as Connect but the dial and the initial
+//subscription are bounded by ctx
+func (c *Client) ConnectContext(ctx context.Context, urls ...string) error {
+	return c.ConnectWithOptions(ctx, nil, urls...)
+}
 
-	c.init()
+//ConnectWithOptions is the same as ConnectContext with the TLS, proxy
+//and timeout options applied to all the nodes
+func (c *Client) ConnectWithOptions(ctx context.Context, opts *DialOptions, urls ...string) error {
+	if len(urls) == 0 {
+		return errors.New("no node url to connect")
+	}
+	nodes := make([]*node, len(urls))
+	for i, url := range urls {
+		n, err := newNode(url, opts)
+		if err != nil {
+			return err
+		}
+		nodes[i] = n
+	}
+	c.closeNodes()
+
+	errs := make([]error, len(urls))
+	wait := new(sync.WaitGroup)
+	for i := range nodes {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			errs[i] = nodes[i].transport.StartContext(ctx)
+		}(i)
+	}
+	wait.Wait()
+
+	stopHealth := make(chan struct{})
+	c.poolMutex.Lock()
+	c.nodes = nodes
+	c.active = nil
+	c.stopHealth = stopHealth
+	c.poolMutex.Unlock()
+	for i, n := range nodes {
+		c.initNode(n)
+		if errs[i] == nil {
+			c.subscribeStreams(ctx, n)
+		}
+	}
+	go c.healthCheck(stopHealth)
+	if !c.failover(ctx) {
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+		return ErrNoNodeConnected
+	}
+	c.mutex.Lock()
+	c.inited = true
+	c.mutex.Unlock()
 	return nil
 }
 
-func (c *Client) reConnect(url string) error {
-	err := c.wm.Disconnect()
-	if err != nil {
-		return err
-	}
-	c.wm.SetUrl(url)
-	err = c.wm.Start()
-	if err != nil {
-		return err
+func (c *Client) initNode(n *node) {
+	go c.processMessage(n)
+	n.transport.OnReconnected(func() {
+		c.onNodeReconnected(n)
+	})
+}
+
+func (c *Client) onNodeReconnected(n *node) {
+	n.mutex.Lock()
+	closed := n.closed
+	n.mutex.Unlock()
+	if closed {
+		return
 	}
-	if !c.inited {
-		c.init()
+	if c.isActive(n) {
+		c.moveSubscriptions(context.Background(), n)
 	} else {
-		//connect changed,only subscribe
-		c.initSubscription()
+		c.subscribeStreams(context.Background(), n)
+	}
+}
+
+// closeNodes disconnect the nodes connected before, stop handling their messages
+// and checking their health
+func (c *Client) closeNodes() {
+	c.poolMutex.Lock()
+	nodes := c.nodes
+	c.nodes = nil
+	c.active = nil
+	if c.stopHealth != nil {
+		close(c.stopHealth)
+		c.stopHealth = nil
+	}
+	c.poolMutex.Unlock()
+	for _, n := range nodes {
+		n.mutex.Lock()
+		n.closed = true
+		n.mutex.Unlock()
+		n.transport.Disconnect()
 	}
-	return nil
 }
 
-func (c *Client) init() {
-	c.sendMsgChan = c.wm.WriteChan()
-	c.recvMsgChan = c.wm.ReadChan()
+//GetWebocketManager return the connection of the active node,
+//nil if it is not a websocket connection
+func (c *Client) GetWebocketManager() *WebsocketManager {
+	n := c.activeNode()
+	if n == nil {
+		return nil
+	}
+	wm, _ := n.transport.(*WebsocketManager)
+	return wm
+}
 
-	go c.processMessage()
-	go c.checkReconnection()
-	c.initSubscription()
-	c.inited = true
+//Streaming reports whether the active node pushes the messages,
+//false for a HTTP node whose tx status is polled without db_success
+func (c *Client) Streaming() bool {
+	n := c.activeNode()
+	return n == nil || n.transport.Streaming()
 }
 
-func (c *Client) checkReconnection() {
-	c.wm.OnReconnected(func() {
-		c.initSubscription()
-	})
+//IsConnected reports whether any of the nodes is connected
+func (c *Client) IsConnected() bool {
+	for _, status := range c.Nodes() {
+		if status.Connected {
+			return true
+		}
+	}
+	return false
 }
 
-func (c *Client) GetWebocketManager() *WebsocketManager {
-	return c.wm
+//Disconnect disconnect all the nodes and stop all the goroutines of the client,
+//Connect can be called again after that
+func (c *Client) Disconnect() {
+	c.closeNodes()
+}
+
+// initSubscription subscribe the streams on every connected node,
+// and the tables and txs on the active one
+func (c *Client) initSubscription(ctx context.Context) {
+	c.poolMutex.RLock()
+	nodes := c.nodes
+	active := c.active
+	c.poolMutex.RUnlock()
+	for _, n := range nodes {
+		if n != active && n.connected() {
+			c.subscribeStreams(ctx, n)
+		}
+	}
+	if active != nil {
+		c.moveSubscriptions(ctx, active)
+	}
 }
 
-func (c *Client) initSubscription() {
+// subscribeStreams subscribe the ledger and server streams on a node
+func (c *Client) subscribeStreams(ctx context.Context, n *node) {
 	type Subscribe struct {
 		common.RequestBase
 		Streams []string `json:"streams"`
 	}
-	c.cmdIDs++
 	subCmd := &Subscribe{
 		RequestBase: common.RequestBase{
 			Command: "subscribe",
-			ID:      c.cmdIDs,
+			ID:      c.nextID(),
 		},
 		Streams: []string{"ledger", "server"},
 	}
-	request := c.syncRequest(subCmd)
+	request, err := c.syncRequestTo(ctx, n, subCmd)
+	if err != nil {
+		fmt.Printf("initSubscription error:%s\n", err)
+		return
+	}
 
 	result, _, _, err := jsonparser.Get([]byte(request.Response.Value), "result")
 	if err != nil {
 		fmt.Printf("initSubscription error:%s\n", err)
 		return
 	}
-	c.ServerInfo.Update(string(result))
+	if index, err := jsonparser.GetInt(result, "ledger_index"); err == nil {
+		n.updateLedger(int(index))
+	}
+	if c.isActive(n) {
+		c.ServerInfo.Update(string(result))
+	}
 }
 
-func (c *Client) processMessage() {
-	for msg := range c.recvMsgChan {
-		go c.handleClientMsg(msg)
+// processMessage dispatch the messages of node n until its transport is closed
+func (c *Client) processMessage(n *node) {
+	for msg := range n.transport.ReadChan() {
+		n.mutex.Lock()
+		closed := n.closed
+		n.mutex.Unlock()
+		if closed {
+			continue
+		}
+		go c.handleClientMsg(n, msg)
 	}
 }
 
-func (c *Client) handleClientMsg(msg string) {
+// handleClientMsg handle a message from node n, only the messages from
+// the active node are dispatched to the subscriptions
+func (c *Client) handleClientMsg(n *node, msg string) {
 	// log.Printf("handleClientMsg: %s", msg)
 	msgType, err := jsonparser.GetString([]byte(msg), "type")
 	if err != nil {
@@ -139,6 +274,15 @@ func (c *Client) handleClientMsg(msg string) {
 	}
 	// fmt.Println(msgType)
 
+	if msgType == "ledgerClosed" {
+		if index, err := jsonparser.GetInt([]byte(msg), "ledger_index"); err == nil {
+			n.updateLedger(int(index))
+		}
+	}
+	if msgType != "response" && !c.isActive(n) {
+		return
+	}
+
 	switch msgType {
 	case "response":
 		c.onResponse(msg)
@@ -194,37 +338,54 @@ func (c *Client) onTableMsg(msg string) {
 
 // GetLedger request for ledger data
 func (c *Client) GetLedger(seq int) string {
+	ledger, err := c.GetLedgerContext(context.Background(), seq)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return ledger
+}
+
+// GetLedgerContext is the same as GetLedger but bounded by ctx
+func (c *Client) GetLedgerContext(ctx context.Context, seq int) (string, error) {
 	type getLedger struct {
 		common.RequestBase
 		LedgerIndex int `json:"ledger_index"`
 	}
-	c.cmdIDs++
 	ledgerReq := &getLedger{
 		RequestBase: common.RequestBase{
 			Command: "ledger",
-			ID:      c.cmdIDs,
+			ID:      c.nextID(),
 		},
 		LedgerIndex: seq,
 	}
-	request := c.syncRequest(ledgerReq)
+	request, err := c.syncRequest(ctx, ledgerReq)
+	if err != nil {
+		return "", err
+	}
 
-	return request.Response.Value
+	return request.Response.Value, nil
 }
 
 // GetLedgerVersion request for ledger version
 func (c *Client) GetLedgerVersion() (int, error) {
+	return c.GetLedgerVersionContext(context.Background())
+}
+
+// GetLedgerVersionContext is the same as GetLedgerVersion but bounded by ctx
+func (c *Client) GetLedgerVersionContext(ctx context.Context) (int, error) {
 	type Request struct {
 		common.RequestBase
 	}
-	c.cmdIDs++
 	ledgerReq := &Request{
 		RequestBase: common.RequestBase{
 			Command: "ledger_current",
-			ID:      c.cmdIDs,
+			ID:      c.nextID(),
 		},
 	}
-	request := c.syncRequest(ledgerReq)
-	err := c.parseResponseError(request)
+	request, err := c.syncRequest(ctx, ledgerReq)
+	if err == nil {
+		err = c.parseResponseError(request)
+	}
 	if err != nil {
 		log.Println("GetLedgerVersion:", err)
 		return 0, err
@@ -248,101 +409,286 @@ func (c *Client) parseResponseError(request *Request) error {
 	return nil
 }
 
+// requestResponse sends a request and returns the whole response,
+// the response with an error status is returned as an error
+func (c *Client) requestResponse(ctx context.Context, v common.IRequest) (string, error) {
+	request, err := c.syncRequest(ctx, v)
+	if err != nil {
+		return "", err
+	}
+	err = c.parseResponseError(request)
+	if err != nil {
+		return "", err
+	}
+	return request.Response.Value, nil
+}
+
+// requestResult is the same as requestResponse but returns the result field only
+func (c *Client) requestResult(ctx context.Context, v common.IRequest) (string, error) {
+	response, err := c.requestResponse(ctx, v)
+	if err != nil {
+		return "", err
+	}
+	result, _, _, err := jsonparser.Get([]byte(response), "result")
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
 // GetAccountInfo request for account_info
 func (c *Client) GetAccountInfo(address string) (string, error) {
+	return c.GetAccountInfoContext(context.Background(), address)
+}
+
+// GetAccountInfoContext is the same as GetAccountInfo but bounded by ctx
+func (c *Client) GetAccountInfoContext(ctx context.Context, address string) (string, error) {
 	type getAccount struct {
 		common.RequestBase
 		Account string `json:"account"`
 	}
-	c.cmdIDs++
 	accountReq := &getAccount{}
-	accountReq.ID = c.cmdIDs
+	accountReq.ID = c.nextID()
 	accountReq.Command = "account_info"
 	accountReq.Account = address
 
-	request := c.syncRequest(accountReq)
+	return c.requestResponse(ctx, accountReq)
+}
 
-	err := c.parseResponseError(request)
-	if err != nil {
-		return "", err
+// GetServerInfo request for server_info
+func (c *Client) GetServerInfo() (string, error) {
+	return c.GetServerInfoContext(context.Background())
+}
+
+// GetServerInfoContext is the same as GetServerInfo but bounded by ctx
+func (c *Client) GetServerInfoContext(ctx context.Context) (string, error) {
+	type Request struct {
+		common.RequestBase
 	}
+	req := &Request{}
+	req.ID = c.nextID()
+	req.Command = "server_info"
 
-	return request.Response.Value, nil
+	return c.requestResponse(ctx, req)
 }
 
 // GetNameInDB request for table nameInDB
 func (c *Client) GetNameInDB(address string, tableName string) (string, error) {
+	return c.GetNameInDBContext(context.Background(), address, tableName)
+}
+
+// GetNameInDBContext is the same as GetNameInDB but bounded by ctx
+func (c *Client) GetNameInDBContext(ctx context.Context, address string, tableName string) (string, error) {
 	type Request struct {
 		common.RequestBase
 		Account   string `json:"account"`
 		TableName string `json:"tablename"`
 	}
-	c.cmdIDs++
 	req := &Request{}
-	req.ID = c.cmdIDs
+	req.ID = c.nextID()
 	req.Command = "g_dbname"
 	req.Account = address
 	req.TableName = tableName
 
-	request := c.syncRequest(req)
-
-	err := c.parseResponseError(request)
+	response, err := c.requestResponse(ctx, req)
 	if err != nil {
 		return "", err
 	}
-	nameInDB, err := jsonparser.GetString([]byte(request.Response.Value), "result", "nameInDB")
+	nameInDB, err := jsonparser.GetString([]byte(response), "result", "nameInDB")
 	if err != nil {
 		return "", err
 	}
 	return nameInDB, nil
 }
 
+// GetTableAuth request for the authorities granted on a table,
+// all users will be returned if accounts is empty
+func (c *Client) GetTableAuth(owner string, tableName string, accounts []string) (string, error) {
+	return c.GetTableAuthContext(context.Background(), owner, tableName, accounts)
+}
+
+// GetTableAuthContext is the same as GetTableAuth but bounded by ctx
+func (c *Client) GetTableAuthContext(ctx context.Context, owner string, tableName string, accounts []string) (string, error) {
+	type Request struct {
+		common.RequestBase
+		Owner     string   `json:"owner"`
+		TableName string   `json:"tablename"`
+		Accounts  []string `json:"accounts,omitempty"`
+	}
+	req := &Request{}
+	req.ID = c.nextID()
+	req.Command = "table_auth"
+	req.Owner = owner
+	req.TableName = tableName
+	req.Accounts = accounts
+
+	return c.requestResult(ctx, req)
+}
+
+// GetUserToken request for the token of a confidential table wrapped with the
+// public key of user, it is empty if the table is not confidential
+func (c *Client) GetUserToken(owner string, tableName string, user string) (string, error) {
+	return c.GetUserTokenContext(context.Background(), owner, tableName, user)
+}
+
+// GetUserTokenContext is the same as GetUserToken but bounded by ctx
+func (c *Client) GetUserTokenContext(ctx context.Context, owner string, tableName string, user string) (string, error) {
+	type TxJSON struct {
+		Owner     string
+		User      string
+		TableName string
+	}
+	type Request struct {
+		common.RequestBase
+		TxJSON TxJSON `json:"tx_json"`
+	}
+	req := &Request{}
+	req.ID = c.nextID()
+	req.Command = "g_userToken"
+	req.TxJSON = TxJSON{Owner: owner, User: user, TableName: tableName}
+
+	response, err := c.requestResponse(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	token, err := jsonparser.GetString([]byte(response), "result", "token")
+	if err == jsonparser.KeyPathNotFoundError {
+		return "", nil
+	}
+	return token, err
+}
+
 //Submit submit a signed transaction
 func (c *Client) Submit(blob string) string {
+	response, err := c.SubmitContext(context.Background(), blob)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return response
+}
+
+//SubmitContext is the same as Submit but bounded by ctx
+func (c *Client) SubmitContext(ctx context.Context, blob string) (string, error) {
 	type Request struct {
 		common.RequestBase
 		TxBlob string `json:"tx_blob"`
 	}
-	c.cmdIDs++
 	req := &Request{}
-	req.ID = c.cmdIDs
+	req.ID = c.nextID()
 	req.Command = "submit"
 	req.TxBlob = blob
 
-	request := c.syncRequest(req)
+	request, err := c.syncRequest(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return request.Response.Value, nil
+}
+
+//SubmitMultiSigned submit a multi-signed transaction in json by submit_multisigned
+func (c *Client) SubmitMultiSigned(txJSON interface{}) string {
+	response, err := c.SubmitMultiSignedContext(context.Background(), txJSON)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return response
+}
+
+//SubmitMultiSignedContext is the same as SubmitMultiSigned but bounded by ctx
+func (c *Client) SubmitMultiSignedContext(ctx context.Context, txJSON interface{}) (string, error) {
+	type Request struct {
+		common.RequestBase
+		TxJSON interface{} `json:"tx_json"`
+	}
+	req := &Request{}
+	req.ID = c.nextID()
+	req.Command = "submit_multisigned"
+	req.TxJSON = txJSON
 
-	return request.Response.Value
+	request, err := c.syncRequest(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return request.Response.Value, nil
 }
 
 //SubscribeTx subscribe a transaction by hash
 func (c *Client) SubscribeTx(hash string, callback export.Callback) {
 	c.Event.SubscribeTx(hash, callback)
+	c.subscribeTx(hash, "subscribe")
+}
+
+//UnSubscribeTx subscribe a transaction by hash
+func (c *Client) UnSubscribeTx(hash string) {
+	c.Event.UnSubscribeTx(hash)
+	c.subscribeTx(hash, "unsubscribe")
+}
 
+func (c *Client) subscribeTx(hash string, command string) {
 	type Request struct {
 		common.RequestBase
 		TxHash string `json:"transaction"`
 	}
 	req := Request{}
-	req.Command = "subscribe"
+	req.Command = command
 	req.TxHash = hash
-	c.asyncRequest(req)
+	if err := c.asyncRequest(req); err != nil {
+		log.Printf("%s tx %s error:%s\n", command, hash, err)
+	}
 }
 
-//UnSubscribeTx subscribe a transaction by hash
-func (c *Client) UnSubscribeTx(hash string) {
-	c.Event.UnSubscribeTx(hash)
+//SubscribeTable subscribe a table by name and owner
+func (c *Client) SubscribeTable(name string, owner string, callback export.Callback) error {
+	return c.SubscribeTableContext(context.Background(), name, owner, callback)
+}
+
+//SubscribeTableContext is the same as SubscribeTable but bounded by ctx,
+//ErrTableSubscription is returned when the active node is connected over http
+func (c *Client) SubscribeTableContext(ctx context.Context, name string, owner string, callback export.Callback) error {
+	if n := c.activeNode(); n != nil && !n.transport.Streaming() {
+		return ErrTableSubscription
+	}
+	c.Event.SubscribeTable(name, owner, callback)
+	err := c.subscribeTable(ctx, name, owner, "subscribe")
+	if err != nil {
+		c.Event.UnSubscribeTable(name, owner)
+	}
+	return err
+}
+
+//UnSubscribeTable unsubscribe a table by name and owner
+func (c *Client) UnSubscribeTable(name string, owner string) error {
+	return c.UnSubscribeTableContext(context.Background(), name, owner)
+}
+
+//UnSubscribeTableContext is the same as UnSubscribeTable but bounded by ctx
+func (c *Client) UnSubscribeTableContext(ctx context.Context, name string, owner string) error {
+	c.Event.UnSubscribeTable(name, owner)
+	return c.subscribeTable(ctx, name, owner, "unsubscribe")
+}
 
+func (c *Client) subscribeTable(ctx context.Context, name string, owner string, command string) error {
 	type Request struct {
 		common.RequestBase
-		TxHash string `json:"transaction"`
+		Owner     string `json:"owner"`
+		TableName string `json:"tablename"`
 	}
-	req := Request{}
-	req.Command = "unsubscribe"
-	req.TxHash = hash
-	c.asyncRequest(req)
+	req := &Request{}
+	req.ID = c.nextID()
+	req.Command = command
+	req.Owner = owner
+	req.TableName = name
+
+	_, err := c.requestResponse(ctx, req)
+	return err
 }
 
 func (c *Client) GetTableData(dataJSON interface{}, bSql bool) (string, error) {
+	return c.GetTableDataContext(context.Background(), dataJSON, bSql)
+}
+
+//GetTableDataContext is the same as GetTableData but bounded by ctx
+func (c *Client) GetTableDataContext(ctx context.Context, dataJSON interface{}, bSql bool) (string, error) {
 	type Request struct {
 		common.RequestBase
 		PublicKey   string      `json:"publicKey"`
@@ -350,19 +696,18 @@ func (c *Client) GetTableData(dataJSON interface{}, bSql bool) (string, error) {
 		SigningData string      `json:"signingData"`
 		TxJSON      interface{} `json:"tx_json"`
 	}
-	c.cmdIDs++
 	req := &Request{}
-	req.ID = c.cmdIDs
+	req.ID = c.nextID()
 	req.Command = "r_get"
 	if bSql {
 		req.Command = "r_get_sql_user"
 	}
 	req.TxJSON = dataJSON
-	accStr, err := crypto.GenerateAccount(c.Auth.Secret)
+	signer, err := c.Auth.KeySigner()
 	if err != nil {
 		return "", err
 	}
-	publicKey, err := jsonparser.GetString([]byte(accStr), "publicKeyHex")
+	publicKey, err := signer.PublicKey()
 	if err != nil {
 		return "", err
 	}
@@ -370,71 +715,203 @@ func (c *Client) GetTableData(dataJSON interface{}, bSql bool) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	signature, err := util.SignPlainData(c.Auth.Secret, string(jsonStr))
+	signature, err := signer.Sign(crypto.Sha512Half(jsonStr), jsonStr)
 	if err != nil {
 		return "", err
 	}
 
-	req.PublicKey = publicKey
+	req.PublicKey = crypto.B2H(publicKey)
 	req.SigningData = string(jsonStr)
-	req.Signature = string(signature)
+	req.Signature = crypto.B2H(signature)
 
-	request := c.syncRequest(req)
-
-	err = c.parseResponseError(request)
+	result, err := c.requestResult(ctx, req)
 	if err != nil {
 		if err.Error() == "Invalid field 'LedgerIndex'." {
-			c.ServerInfo.Updated = false
+			c.ServerInfo.Invalidate()
 		}
 		return "", err
 	}
+	return result, nil
+}
 
-	result, _, _, err := jsonparser.Get([]byte(request.Response.Value), "result")
-	if err != nil {
-		// log.Printf("Cleint::GetTableData %s\n",err)
-		return "", err
+func (c *Client) nextID() int64 {
+	return atomic.AddInt64(&c.cmdIDs, 1)
+}
+
+// syncRequest sends a request to the active node and waits for the response,
+// it is sent again to another node when the active one times out and fails over
+func (c *Client) syncRequest(ctx context.Context, v common.IRequest) (*Request, error) {
+	c.poolMutex.RLock()
+	maxTries := len(c.nodes)
+	c.poolMutex.RUnlock()
+	for tries := 1; ; tries++ {
+		n := c.activeNode()
+		if n == nil || !n.connected() {
+			c.failover(ctx)
+			n = c.activeNode()
+		}
+		if n == nil {
+			return nil, ErrNoNodeConnected
+		}
+		request, err := c.syncRequestTo(ctx, n, v)
+		if err == nil {
+			n.resetFailures()
+			return request, nil
+		}
+		// only the default timeout fails over, not the deadline of ctx
+		if err != context.DeadlineExceeded || ctx.Err() != nil || tries >= maxTries {
+			return nil, err
+		}
+		n.addFailure()
+		if !c.failover(ctx) || c.activeNode() == n {
+			return nil, err
+		}
+		log.Printf("request %d timeout on %s, retry on %s\n", v.GetID(), n.url, c.activeNode().url)
 	}
-	// log.Printf("type:%T\n",result)
-	return string(result), nil
 }
 
-func (c *Client) syncRequest(v common.IRequest) *Request {
-	data, _ := json.Marshal(v)
+// syncRequestTo sends a request to node n and waits for the response until ctx is done,
+// util.REQUEST_TIMEOUT is applied when ctx has no deadline.
+// The pending request is dropped and ctx.Err() returned when ctx is done first.
+func (c *Client) syncRequestTo(ctx context.Context, n *node, v common.IRequest) (*Request, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, util.REQUEST_TIMEOUT*time.Second)
+		defer cancel()
+	}
+	data := c.marshalRequest(v)
 	request := NewRequest(v.GetID(), string(data))
 	request.Wait.Add(1)
-	c.sendRequest(request)
 	done := make(chan struct{})
 	go func() {
 		request.Wait.Wait()
 		close(done)
 	}()
+	err := c.sendRequest(ctx, n, request)
+	if err != nil {
+		return nil, err
+	}
 	select {
 	case <-done:
-	case <-time.After(util.REQUEST_TIMEOUT * time.Second):
-		{
-			timeOutMsg := string(`{
-				"status":"error",
-				"error_message":"request timeout"
-			}`)
-			request.Response = &Response{
-				Value:   timeOutMsg,
-				Request: request,
-			}
+		return request, nil
+	case <-ctx.Done():
+		if !c.cancelRequest(request) {
+			// the response won the race and is already stored
+			<-done
+			return request, nil
 		}
+		return nil, ctx.Err()
 	}
-	return request
 }
 
-func (c *Client) sendRequest(request *Request) {
+func (c *Client) sendRequest(ctx context.Context, n *node, request *Request) error {
 	c.mutex.Lock()
 	c.requests[request.ID] = request
 	c.mutex.Unlock()
 
 	// log.Printf("sendRequest %s\n", request.JSON)
-	c.sendMsgChan <- request.JSON
+	select {
+	case n.transport.WriteChan() <- request.JSON:
+		return nil
+	case <-ctx.Done():
+		c.cancelRequest(request)
+		return ctx.Err()
+	}
+}
+
+// cancelRequest removes a pending request and releases its waiter,
+// false is returned if the request has already been answered
+func (c *Client) cancelRequest(request *Request) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.requests[request.ID]; !ok {
+		return false
+	}
+	delete(c.requests, request.ID)
+	request.Wait.Done()
+	return true
+}
+
+// errorResponse formats err the same way as an error response from the node
+func errorResponse(err error) string {
+	msg, _ := json.Marshal(map[string]string{
+		"status":        "error",
+		"error_message": err.Error(),
+	})
+	return string(msg)
+}
+
+// asyncRequest send a request without waiting for the response,
+// it is dropped with an error if the node does not take it in time
+func (c *Client) asyncRequest(v interface{}) error {
+	n := c.activeNode()
+	if n == nil {
+		return ErrNoNodeConnected
+	}
+	data := c.marshalRequest(v)
+	timer := time.NewTimer(util.REQUEST_TIMEOUT * time.Second)
+	defer timer.Stop()
+	select {
+	case n.transport.WriteChan() <- string(data):
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("request to %s dropped, the node is not writable", n.url)
+	}
 }
 
-func (c *Client) asyncRequest(v interface{}) {
+// marshalRequest marshal a request and stamp the schema_id on it
+func (c *Client) marshalRequest(v interface{}) []byte {
 	data, _ := json.Marshal(v)
-	c.sendMsgChan <- string(data)
+	if schemaID := c.GetSchemaID(); schemaID != "" {
+		stamped, err := jsonparser.Set(data, []byte(strconv.Quote(schemaID)), "schema_id")
+		if err != nil {
+			log.Printf("marshalRequest error:%s\n", err)
+			return data
+		}
+		data = stamped
+	}
+	return data
+}
+
+// SetSchema route the subsequent requests to the schema with id,
+// the main chain is used when id is empty
+func (c *Client) SetSchema(id string) {
+	c.mutex.Lock()
+	c.schemaID = id
+	inited := c.inited
+	c.mutex.Unlock()
+	c.ServerInfo.Invalidate()
+	c.Sequences.ResetAll()
+	if inited {
+		c.initSubscription(context.Background())
+	}
+}
+
+// GetSchemaID return the schema id the requests are routed to
+func (c *Client) GetSchemaID() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.schemaID
+}
+
+// GetSchemaList request for the schemas related to account,
+// only running schemas are returned when running is true
+func (c *Client) GetSchemaList(account string, running *bool) (string, error) {
+	return c.GetSchemaListContext(context.Background(), account, running)
+}
+
+// GetSchemaListContext is the same as GetSchemaList but bounded by ctx
+func (c *Client) GetSchemaListContext(ctx context.Context, account string, running *bool) (string, error) {
+	type Request struct {
+		common.RequestBase
+		Account string `json:"account,omitempty"`
+		Running *bool  `json:"running,omitempty"`
+	}
+	req := &Request{}
+	req.ID = c.nextID()
+	req.Command = "schema_list"
+	req.Account = account
+	req.Running = running
+
+	return c.requestResult(ctx, req)
 }
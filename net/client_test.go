@@ -0,0 +1,94 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChainSQL/go-chainsql-api/common"
+)
+
+func TestSyncRequestCanceled(t *testing.T) {
+	c := NewClient()
+	n, err := newNode("ws://127.0.0.1:6006", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.transport.(*WebsocketManager).sendMsgChan = make(chan string, 1)
+	c.nodes = []*node{n}
+	c.active = n
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = c.GetLedgerContext(ctx, 1)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected %s, got %v", context.DeadlineExceeded, err)
+	}
+	if len(c.requests) != 0 {
+		t.Fatalf("expected no pending request, got %d", len(c.requests))
+	}
+
+	// the send channel is full now, cancel while sending
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = c.GetLedgerContext(ctx, 2)
+	if err != context.Canceled {
+		t.Fatalf("expected %s, got %v", context.Canceled, err)
+	}
+	if len(c.requests) != 0 {
+		t.Fatalf("expected no pending request, got %d", len(c.requests))
+	}
+}
+
+func TestPrepareTableCanceled(t *testing.T) {
+	c := NewClient()
+	n, err := newNode("ws://127.0.0.1:6006", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.transport.(*WebsocketManager).sendMsgChan = make(chan string, 2)
+	c.nodes = []*node{n}
+	c.active = n
+	c.Auth.Address = "zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh"
+	c.Auth.Owner = c.Auth.Address
+
+	// both requests fail at once, run with -race
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := PrepareTableContext(ctx, c, "t1"); err != context.DeadlineExceeded {
+		t.Fatalf("expected %s, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestMarshalRequestSchemaID(t *testing.T) {
+	type Request struct {
+		common.RequestBase
+		Account string `json:"account"`
+	}
+	req := &Request{Account: "zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh"}
+	req.ID = 1
+	req.Command = "account_info"
+
+	c := NewClient()
+	if data := string(c.marshalRequest(req)); data != `{"command":"account_info","id":1,"account":"zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh"}` {
+		t.Fatalf("expected no schema_id, got %s", data)
+	}
+
+	schemaID := "F3A5A8B3A1F3D6E1B1F7F4B8C1D8E6C3F5E1A2B3C4D5E6F708192A3B4C5D6E7F"
+	c.SetSchema(schemaID)
+	var stamped map[string]interface{}
+	if err := json.Unmarshal(c.marshalRequest(req), &stamped); err != nil {
+		t.Fatal(err)
+	}
+	if stamped["schema_id"] != schemaID || stamped["command"] != "account_info" || stamped["account"] != req.Account || stamped["id"] != float64(1) {
+		t.Fatalf("unexpected request %v", stamped)
+	}
+
+	// the main chain again
+	c.SetSchema("")
+	if data := string(c.marshalRequest(req)); strings.Contains(data, "schema_id") {
+		t.Fatalf("expected no schema_id, got %s", data)
+	}
+}
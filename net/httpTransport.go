@@ -0,0 +1,410 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ChainSQL/go-chainsql-api/util"
+	"github.com/buger/jsonparser"
+)
+
+//PollInterval is the interval in seconds HTTPTransport polls for the subscribed ledger and txs
+const PollInterval = 1
+
+//ErrTableSubscription is returned subscribing a table over http,
+//the table messages are only pushed by the node over websocket
+var ErrTableSubscription = errors.New("table subscriptions are not supported over http")
+
+// HTTPTransport is a Transport over the JSON-RPC port of a node,
+// the subscriptions of the ledger stream and txs are emulated by polling
+// server_state and tx, table subscriptions are not supported
+type HTTPTransport struct {
+	url           string
+	client        *http.Client
+	header        http.Header
+	sendMsgChan   chan string
+	recvMsgChan   chan string
+	connected     bool
+	started       bool
+	ledgerSub     bool
+	lastLedger    int64
+	txSubs        map[string]bool
+	onReconnected Reconnected
+	ctx           context.Context
+	cancel        context.CancelFunc
+	closeOnce     *sync.Once
+	routines      *sync.WaitGroup
+	mutex         *sync.Mutex
+}
+
+// NewHTTPTransport is a constructor, an error is returned for invalid options like a bad ProxyURL
+func NewHTTPTransport(url string, opts *DialOptions) (*HTTPTransport, error) {
+	client, err := opts.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HTTPTransport{
+		url:         url,
+		client:      client,
+		header:      opts.header(),
+		sendMsgChan: make(chan string, 1024),
+		recvMsgChan: make(chan string, 1024),
+		txSubs:      make(map[string]bool),
+		ctx:         ctx,
+		cancel:      cancel,
+		closeOnce:   new(sync.Once),
+		routines:    new(sync.WaitGroup),
+		mutex:       new(sync.Mutex),
+	}, nil
+}
+
+//StartContext check the node is reachable and start handling the requests
+func (h *HTTPTransport) StartContext(ctx context.Context) error {
+	if h.ctx.Err() != nil {
+		return ErrClosed
+	}
+	_, err := h.call(ctx, "server_state", map[string]interface{}{})
+	if err != nil {
+		log.Printf("connecting to %s failed,err:%s", h.url, err.Error())
+		return err
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.ctx.Err() != nil {
+		return ErrClosed
+	}
+	h.connected = true
+	if !h.started {
+		h.started = true
+		h.routines.Add(2)
+		go h.sendLoop()
+		go h.pollLoop()
+	}
+	return nil
+}
+
+//Started reports whether StartContext has succeeded
+func (h *HTTPTransport) Started() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.started
+}
+
+//WriteChan return the write channel
+func (h *HTTPTransport) WriteChan() chan string {
+	return h.sendMsgChan
+}
+
+//ReadChan return the channel used to read
+func (h *HTTPTransport) ReadChan() chan string {
+	return h.recvMsgChan
+}
+
+//IsConnected reports whether the last request to the node succeeded
+func (h *HTTPTransport) IsConnected() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.connected
+}
+
+//Disconnect stop handling the requests and polling, wait for all the
+//goroutines to exit and close ReadChan, the transport can not be started again
+func (h *HTTPTransport) Disconnect() error {
+	h.closeOnce.Do(func() {
+		h.mutex.Lock()
+		h.cancel()
+		h.connected = false
+		h.mutex.Unlock()
+		h.routines.Wait()
+		close(h.recvMsgChan)
+	})
+	return nil
+}
+
+//OnReconnected set the callback called when the node is reachable again
+func (h *HTTPTransport) OnReconnected(cb Reconnected) {
+	h.mutex.Lock()
+	h.onReconnected = cb
+	h.mutex.Unlock()
+}
+
+//Streaming is false, the messages are emulated by polling
+func (h *HTTPTransport) Streaming() bool {
+	return false
+}
+
+// call post a JSON-RPC request and return its result
+func (h *HTTPTransport) call(ctx context.Context, method string, params map[string]interface{}) ([]byte, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"method": method,
+		"params": []interface{}{params},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range h.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s", resp.Status, data)
+	}
+	result, _, _, err := jsonparser.Get(data, "result")
+	if err != nil {
+		return nil, fmt.Errorf("invalid response %s", data)
+	}
+	return result, nil
+}
+
+func (h *HTTPTransport) sendLoop() {
+	defer h.routines.Done()
+	for {
+		select {
+		case msg := <-h.sendMsgChan:
+			h.routines.Add(1)
+			go h.handle(msg)
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
+
+// handle convert a websocket request into a JSON-RPC call,
+// and push the result back in the format of a websocket response
+func (h *HTTPTransport) handle(msg string) {
+	defer h.routines.Done()
+	var params map[string]interface{}
+	err := json.Unmarshal([]byte(msg), &params)
+	if err != nil {
+		log.Printf("HTTPTransport handle error:%s\n", err)
+		return
+	}
+	id := params["id"]
+	command, _ := params["command"].(string)
+	delete(params, "id")
+	delete(params, "command")
+
+	if command == "subscribe" || command == "unsubscribe" {
+		result, err := h.subscribe(command == "subscribe", params)
+		h.respond(id, result, err)
+		return
+	}
+	result, err := h.call(h.ctx, command, params)
+	if h.ctx.Err() != nil {
+		return
+	}
+	h.setConnected(err == nil)
+	h.respond(id, result, err)
+}
+
+func (h *HTTPTransport) respond(id interface{}, result []byte, err error) {
+	if id == nil {
+		return
+	}
+	response := map[string]interface{}{
+		"id":   id,
+		"type": "response",
+	}
+	if err != nil {
+		response["status"] = "error"
+		response["error"] = "httpError"
+		response["error_message"] = err.Error()
+	} else {
+		status, _ := jsonparser.GetString(result, "status")
+		response["status"] = status
+		response["result"] = json.RawMessage(result)
+		if status == "error" {
+			response["error"], _ = jsonparser.GetString(result, "error")
+			response["error_message"], _ = jsonparser.GetString(result, "error_message")
+		}
+	}
+	h.push(response)
+}
+
+func (h *HTTPTransport) push(msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("HTTPTransport push error:%s\n", err)
+		return
+	}
+	select {
+	case h.recvMsgChan <- string(data):
+	case <-h.ctx.Done():
+	}
+}
+
+func (h *HTTPTransport) setConnected(connected bool) {
+	h.mutex.Lock()
+	h.connected = connected
+	h.mutex.Unlock()
+}
+
+// subscribe record the ledger stream and tx subscriptions to poll for
+func (h *HTTPTransport) subscribe(subscribe bool, params map[string]interface{}) ([]byte, error) {
+	if _, ok := params["streams"]; ok {
+		h.mutex.Lock()
+		h.ledgerSub = subscribe
+		h.mutex.Unlock()
+		if !subscribe {
+			return []byte(`{"status":"success"}`), nil
+		}
+		state, err := h.call(h.ctx, "server_state", map[string]interface{}{})
+		if err != nil {
+			return nil, err
+		}
+		ledger := ledgerFromState(state)
+		ledger["status"] = "success"
+		return json.Marshal(ledger)
+	}
+	if hash, ok := params["transaction"].(string); ok {
+		h.mutex.Lock()
+		if subscribe {
+			h.txSubs[hash] = true
+		} else {
+			delete(h.txSubs, hash)
+		}
+		h.mutex.Unlock()
+		return []byte(`{"status":"success"}`), nil
+	}
+	if _, ok := params["tablename"]; ok {
+		return nil, ErrTableSubscription
+	}
+	return nil, errors.New("only the ledger stream and transactions can be subscribed over http")
+}
+
+// ledgerFromState convert the result of server_state to the fields of a ledgerClosed message
+func ledgerFromState(state []byte) map[string]interface{} {
+	ledger := make(map[string]interface{})
+	if seq, err := jsonparser.GetInt(state, "state", "validated_ledger", "seq"); err == nil {
+		ledger["ledger_index"] = seq
+	}
+	if hash, err := jsonparser.GetString(state, "state", "validated_ledger", "hash"); err == nil {
+		ledger["ledger_hash"] = hash
+	}
+	if fee, err := jsonparser.GetInt(state, "state", "validated_ledger", "base_fee"); err == nil {
+		ledger["fee_base"] = fee
+	}
+	if loadBase, err := jsonparser.GetInt(state, "state", "load_base"); err == nil {
+		ledger["load_base"] = loadBase
+	}
+	if loadFactor, err := jsonparser.GetInt(state, "state", "load_factor"); err == nil {
+		ledger["load_factor"] = loadFactor
+	}
+	return ledger
+}
+
+func (h *HTTPTransport) pollLoop() {
+	defer h.routines.Done()
+	ticker := time.NewTicker(PollInterval * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			h.poll()
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
+
+// poll check the node is reachable, push a ledgerClosed message when a new
+// ledger is validated, and a singleTransaction message when a tx is validated.
+// Each poll is bounded by util.REQUEST_TIMEOUT seconds and canceled by Disconnect
+func (h *HTTPTransport) poll() {
+	ctx, cancel := context.WithTimeout(h.ctx, util.REQUEST_TIMEOUT*time.Second)
+	defer cancel()
+	state, err := h.call(ctx, "server_state", map[string]interface{}{})
+	if h.ctx.Err() != nil {
+		return
+	}
+	h.mutex.Lock()
+	wasConnected := h.connected
+	h.connected = err == nil
+	onReconnected := h.onReconnected
+	ledgerSub := h.ledgerSub
+	hashes := make([]string, 0, len(h.txSubs))
+	for hash := range h.txSubs {
+		hashes = append(hashes, hash)
+	}
+	h.mutex.Unlock()
+	if err != nil {
+		return
+	}
+	if !wasConnected && onReconnected != nil {
+		go onReconnected()
+	}
+
+	if ledgerSub {
+		ledger := ledgerFromState(state)
+		seq, _ := ledger["ledger_index"].(int64)
+		h.mutex.Lock()
+		newLedger := seq > h.lastLedger
+		if newLedger {
+			h.lastLedger = seq
+		}
+		h.mutex.Unlock()
+		if newLedger {
+			ledger["type"] = "ledgerClosed"
+			h.push(ledger)
+		}
+	}
+	for _, hash := range hashes {
+		h.pollTx(ctx, hash)
+	}
+}
+
+func (h *HTTPTransport) pollTx(ctx context.Context, hash string) {
+	result, err := h.call(ctx, "tx", map[string]interface{}{"transaction": hash})
+	if err != nil {
+		return
+	}
+	if validated, _ := jsonparser.GetBoolean(result, "validated"); !validated {
+		return
+	}
+	h.mutex.Lock()
+	_, ok := h.txSubs[hash]
+	delete(h.txSubs, hash)
+	h.mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	txType, _ := jsonparser.GetString(result, "TransactionType")
+	engineResult, _ := jsonparser.GetString(result, "meta", "TransactionResult")
+	msg := map[string]interface{}{
+		"type":   "singleTransaction",
+		"status": util.ValidateSuccess,
+		"transaction": map[string]string{
+			"hash":            hash,
+			"TransactionType": txType,
+		},
+	}
+	if engineResult != "tesSUCCESS" {
+		msg["status"] = util.ValidateError
+		msg["error"] = engineResult
+		msg["error_message"] = engineResult
+	}
+	h.push(msg)
+}
@@ -0,0 +1,95 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newStateServer answer server_state, the polls after the first hang
+// until their request is canceled or release is closed when hang is set
+func newStateServer(t *testing.T, hang bool, release chan struct{}) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) > 1 && hang {
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]interface{}{
+			"status": "success",
+			"state":  map[string]interface{}{"validated_ledger": map[string]interface{}{"seq": 100}},
+		}})
+	}))
+	return server, &calls
+}
+
+func TestHTTPTransportPollStops(t *testing.T) {
+	server, calls := newStateServer(t, false, nil)
+	defer server.Close()
+	h, err := NewHTTPTransport(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.StartContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(PollInterval*time.Second + 200*time.Millisecond)
+	if n := atomic.LoadInt32(calls); n < 2 {
+		t.Fatalf("expected the node polled, got %d calls", n)
+	}
+	h.Disconnect()
+	stopped := atomic.LoadInt32(calls)
+	time.Sleep(PollInterval*time.Second + 200*time.Millisecond)
+	if n := atomic.LoadInt32(calls); n != stopped {
+		t.Fatalf("expected the polling stopped at %d calls, got %d", stopped, n)
+	}
+}
+
+func TestHTTPTransportDisconnectWhilePolling(t *testing.T) {
+	release := make(chan struct{})
+	server, calls := newStateServer(t, true, release)
+	defer server.Close()
+	defer close(release)
+	h, err := NewHTTPTransport(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.StartContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200 && atomic.LoadInt32(calls) < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the poll hanging on the node is canceled
+	done := make(chan struct{})
+	go func() {
+		h.Disconnect()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Disconnect to cancel the poll in flight")
+	}
+}
+
+func TestHTTPSubscribeTable(t *testing.T) {
+	server, _ := newStateServer(t, false, nil)
+	defer server.Close()
+	c := NewClient()
+	if err := c.Connect(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	if err := c.SubscribeTable("t1", "zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh", nil); err != ErrTableSubscription {
+		t.Fatalf("expected %s, got %v", ErrTableSubscription, err)
+	}
+}
@@ -0,0 +1,97 @@
+package net
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func newTLSEchoServer(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade error:%s", err)
+			return
+		}
+		defer conn.Close()
+		for {
+			mt, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(mt, msg)
+		}
+	}))
+}
+
+func TestDialOptions(t *testing.T) {
+	server := newTLSEchoServer(t)
+	defer server.Close()
+	url := "wss" + strings.TrimPrefix(server.URL, "https")
+	header := http.Header{"X-Token": []string{"secret"}}
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	newManager := func(opts *DialOptions) *WebsocketManager {
+		wsc, err := NewWsClientManagerWithOptions(url, 1, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wsc
+	}
+	if err := newManager(&DialOptions{Header: header}).Start(); err == nil {
+		t.Fatal("expected the unknown certificate to fail")
+	}
+	if err := newManager(&DialOptions{RootCAs: roots}).Start(); err == nil {
+		t.Fatal("expected the handshake without header to fail")
+	}
+
+	wsc := newManager(&DialOptions{RootCAs: roots, Header: header})
+	if err := wsc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer wsc.Disconnect()
+	wsc.WriteChan() <- "ping"
+	if msg := <-wsc.ReadChan(); msg != "ping" {
+		t.Fatalf("expected ping, got %s", msg)
+	}
+
+	insecure := newManager(&DialOptions{InsecureSkipVerify: true, Header: header})
+	if err := insecure.Start(); err != nil {
+		t.Fatal(err)
+	}
+	insecure.Disconnect()
+
+	if websocket.DefaultDialer.HandshakeTimeout != 45*time.Second {
+		t.Fatalf("websocket.DefaultDialer was changed")
+	}
+	badProxy := &DialOptions{ProxyURL: "127.0.0.1"}
+	if err := NewClient().ConnectWithOptions(context.Background(), badProxy, url); err == nil {
+		t.Fatal("expected the invalid proxy url to fail")
+	}
+	if _, err := NewWsClientManagerWithOptions(url, 1, badProxy); err == nil {
+		t.Fatal("expected the invalid proxy url to fail the websocket manager")
+	}
+	if _, err := NewHTTPTransport(server.URL, badProxy); err == nil {
+		t.Fatal("expected the invalid proxy url to fail the http transport")
+	}
+
+	// the http requests are bounded by the ctx of the calls unless RequestTimeout is set
+	if h, err := NewHTTPTransport(server.URL, nil); err != nil || h.client.Timeout != 0 {
+		t.Fatalf("expected no http timeout by default, got %v:%v", h, err)
+	}
+	if h, err := NewHTTPTransport(server.URL, &DialOptions{RequestTimeout: time.Minute}); err != nil || h.client.Timeout != time.Minute {
+		t.Fatalf("expected the RequestTimeout applied, got %v:%v", h, err)
+	}
+}
@@ -0,0 +1,304 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ChainSQL/go-chainsql-api/common"
+	"github.com/buger/jsonparser"
+)
+
+const (
+	//HealthCheckInterval is the interval in seconds to check the health of the nodes
+	HealthCheckInterval = 5
+	//MaxLedgerLag is the ledgers a node may fall behind the others before failing over
+	MaxLedgerLag = 3
+	//MaxNodeFailures is the request timeouts in a row before failing over
+	MaxNodeFailures = 2
+)
+
+// ErrNoNodeConnected is returned when none of the nodes is connected
+var ErrNoNodeConnected = errors.New("no node connected")
+
+// node is one of the connections in the pool of a Client
+type node struct {
+	url         string
+	transport   Transport
+	ledgerIndex int
+	latency     time.Duration
+	serverState string
+	failures    int
+	closed      bool
+	mutex       *sync.Mutex
+}
+
+// NodeStatus is the health of a node reported by Client.Nodes
+type NodeStatus struct {
+	URL         string
+	Connected   bool
+	Active      bool
+	LedgerIndex int
+	Latency     time.Duration
+	ServerState string
+	Failures    int
+}
+
+func newNode(url string, opts *DialOptions) (*node, error) {
+	transport, err := NewTransport(url, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &node{
+		url:       url,
+		transport: transport,
+		mutex:     new(sync.Mutex),
+	}, nil
+}
+
+func (n *node) connected() bool {
+	return n.transport.IsConnected()
+}
+
+func (n *node) updateLedger(index int) {
+	n.mutex.Lock()
+	if index > n.ledgerIndex {
+		n.ledgerIndex = index
+	}
+	n.mutex.Unlock()
+}
+
+func (n *node) addFailure() {
+	n.mutex.Lock()
+	n.failures++
+	n.mutex.Unlock()
+}
+
+func (n *node) resetFailures() {
+	n.mutex.Lock()
+	n.failures = 0
+	n.mutex.Unlock()
+}
+
+func (n *node) status() NodeStatus {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return NodeStatus{
+		URL:         n.url,
+		Connected:   n.connected(),
+		LedgerIndex: n.ledgerIndex,
+		Latency:     n.latency,
+		ServerState: n.serverState,
+		Failures:    n.failures,
+	}
+}
+
+// healthy reports whether the node can serve requests,
+// maxLedger is the highest ledger seen in the pool
+func (s NodeStatus) healthy(maxLedger int) bool {
+	if !s.Connected || s.Failures >= MaxNodeFailures {
+		return false
+	}
+	if maxLedger-s.LedgerIndex > MaxLedgerLag {
+		return false
+	}
+	switch s.ServerState {
+	case "", "full", "proposing", "validating":
+		return true
+	}
+	return false
+}
+
+// score rate a node by ledger lag, failures, latency and server_state, lower is better
+func (s NodeStatus) score(maxLedger int) int64 {
+	score := int64(maxLedger-s.LedgerIndex) * 1000
+	score += int64(s.Failures) * 10000
+	score += s.Latency.Milliseconds()
+	if !s.healthy(maxLedger) {
+		score += 1000000
+	}
+	return score
+}
+
+// Nodes return the health of the nodes in the pool
+func (c *Client) Nodes() []NodeStatus {
+	c.poolMutex.RLock()
+	defer c.poolMutex.RUnlock()
+	statuses := make([]NodeStatus, 0, len(c.nodes))
+	for _, n := range c.nodes {
+		status := n.status()
+		status.Active = n == c.active
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// activeNode return the node the requests are sent to
+func (c *Client) activeNode() *node {
+	c.poolMutex.RLock()
+	defer c.poolMutex.RUnlock()
+	return c.active
+}
+
+func (c *Client) isActive(n *node) bool {
+	return c.activeNode() == n
+}
+
+// selectNode pick the healthiest connected node, nil if none is connected
+func (c *Client) selectNode() *node {
+	c.poolMutex.RLock()
+	nodes := c.nodes
+	c.poolMutex.RUnlock()
+
+	statuses := make([]NodeStatus, len(nodes))
+	maxLedger := 0
+	for i, n := range nodes {
+		statuses[i] = n.status()
+		if statuses[i].Connected && statuses[i].LedgerIndex > maxLedger {
+			maxLedger = statuses[i].LedgerIndex
+		}
+	}
+	var best *node
+	var bestScore int64
+	for i, n := range nodes {
+		if !statuses[i].Connected {
+			continue
+		}
+		score := statuses[i].score(maxLedger)
+		if best == nil || score < bestScore {
+			best = n
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// activeHealthy reports whether the active node is still good to use
+func (c *Client) activeHealthy() bool {
+	active := c.activeNode()
+	if active == nil {
+		return false
+	}
+	maxLedger := 0
+	for _, status := range c.Nodes() {
+		if status.Connected && status.LedgerIndex > maxLedger {
+			maxLedger = status.LedgerIndex
+		}
+	}
+	return active.status().healthy(maxLedger)
+}
+
+// failover switch to the healthiest node when the active one is unhealthy,
+// the table and tx subscriptions are moved to the new node.
+// false is returned if no other node is available
+func (c *Client) failover(ctx context.Context) bool {
+	c.failoverMutex.Lock()
+	defer c.failoverMutex.Unlock()
+	if c.activeHealthy() {
+		return true
+	}
+	best := c.selectNode()
+	if best == nil {
+		return false
+	}
+	c.poolMutex.Lock()
+	old := c.active
+	c.active = best
+	c.poolMutex.Unlock()
+	if best == old {
+		return false
+	}
+	if old != nil {
+		log.Printf("failover from %s to %s\n", old.url, best.url)
+	}
+	c.ServerInfo.Invalidate()
+	c.moveSubscriptions(ctx, best)
+	return true
+}
+
+// moveSubscriptions subscribe the ledger, tables and txs on the new active node
+func (c *Client) moveSubscriptions(ctx context.Context, n *node) {
+	c.subscribeStreams(ctx, n)
+	for _, table := range c.Event.GetTableSubscriptions() {
+		err := c.subscribeTable(ctx, table.Name, table.Owner, "subscribe")
+		if err != nil {
+			log.Printf("moveSubscriptions table %s error:%s\n", table.Name, err)
+		}
+	}
+	for _, hash := range c.Event.GetTxSubscriptions() {
+		c.subscribeTx(hash, "subscribe")
+	}
+}
+
+// healthCheck measure the latency and state of the nodes periodically,
+// and fail over when the active node is unhealthy, until stop is closed
+func (c *Client) healthCheck(stop chan struct{}) {
+	ticker := time.NewTicker(HealthCheckInterval * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+		c.poolMutex.RLock()
+		nodes := c.nodes
+		c.poolMutex.RUnlock()
+		for _, n := range nodes {
+			if !n.connected() {
+				c.startNode(n)
+				continue
+			}
+			c.checkNode(n)
+		}
+		if !c.activeHealthy() {
+			c.failover(context.Background())
+		}
+	}
+}
+
+// startNode connect a node whose first dial failed,
+// the reconnection after that is done by its transport
+func (c *Client) startNode(n *node) {
+	n.mutex.Lock()
+	closed := n.closed
+	n.mutex.Unlock()
+	if closed || n.transport.Started() {
+		return
+	}
+	if n.transport.StartContext(context.Background()) == nil {
+		c.subscribeStreams(context.Background(), n)
+	}
+}
+
+// checkNode request server_info from a node for its latency and state
+func (c *Client) checkNode(n *node) {
+	type Request struct {
+		common.RequestBase
+	}
+	req := &Request{}
+	req.ID = c.nextID()
+	req.Command = "server_info"
+	ctx, cancel := context.WithTimeout(context.Background(), HealthCheckInterval*time.Second)
+	defer cancel()
+	start := time.Now()
+	request, err := c.syncRequestTo(ctx, n, req)
+	if err != nil {
+		n.addFailure()
+		return
+	}
+	latency := time.Since(start)
+	response := []byte(request.Response.Value)
+	state, _ := jsonparser.GetString(response, "result", "info", "server_state")
+	seq, err := jsonparser.GetInt(response, "result", "info", "validated_ledger", "seq")
+	if err == nil {
+		n.updateLedger(int(seq))
+	}
+	n.mutex.Lock()
+	n.latency = latency
+	n.serverState = state
+	n.failures = 0
+	n.mutex.Unlock()
+}
@@ -0,0 +1,169 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// tableNode is a node answering every request with success,
+// the table subscriptions received are sent to subscribed
+type tableNode struct {
+	server     *httptest.Server
+	url        string
+	subscribed chan string
+	mutex      sync.Mutex
+	conns      []*websocket.Conn
+}
+
+func newTableNode(t *testing.T) *tableNode {
+	n := &tableNode{subscribed: make(chan string, 16)}
+	upgrader := websocket.Upgrader{}
+	n.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade error:%s", err)
+			return
+		}
+		n.mutex.Lock()
+		n.conns = append(n.conns, conn)
+		n.mutex.Unlock()
+		for {
+			var req map[string]interface{}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if name, ok := req["tablename"].(string); ok {
+				n.subscribed <- req["command"].(string) + " " + name + " " + req["owner"].(string)
+			}
+			n.mutex.Lock()
+			conn.WriteJSON(map[string]interface{}{
+				"id":     req["id"],
+				"type":   "response",
+				"status": "success",
+				"result": map[string]interface{}{},
+			})
+			n.mutex.Unlock()
+		}
+	}))
+	n.url = "ws" + strings.TrimPrefix(n.server.URL, "http")
+	return n
+}
+
+// drop closes all the connections, the client reconnects unless the server is closed
+func (n *tableNode) drop() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for _, conn := range n.conns {
+		conn.Close()
+	}
+	n.conns = nil
+}
+
+func (n *tableNode) push(t *testing.T, msg string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for _, conn := range n.conns {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func (n *tableNode) expect(t *testing.T, subscription string) {
+	select {
+	case got := <-n.subscribed:
+		if got != subscription {
+			t.Fatalf("expected %s on %s, got %s", subscription, n.url, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no %s on %s", subscription, n.url)
+	}
+}
+
+func (n *tableNode) expectNone(t *testing.T) {
+	select {
+	case got := <-n.subscribed:
+		t.Fatalf("unexpected %s on %s", got, n.url)
+	default:
+	}
+}
+
+func TestTableSubscriptionMoved(t *testing.T) {
+	first := newTableNode(t)
+	defer first.server.Close()
+	second := newTableNode(t)
+	defer second.server.Close()
+
+	c := NewClient()
+	if err := c.Connect(first.url); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	received := make(chan string, 4)
+	if err := c.SubscribeTable("t1", "owner1", func(msg string) {
+		received <- msg
+	}); err != nil {
+		t.Fatal(err)
+	}
+	first.expect(t, "subscribe t1 owner1")
+
+	table := `{"type":"table","tablename":"t1","owner":"owner1"}`
+	first.push(t, `{"type":"table","tablename":"t1","owner":"owner2"}`)
+	first.push(t, table)
+	select {
+	case msg := <-received:
+		if msg != table {
+			t.Fatalf("expected %s, got %s", table, msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no table message")
+	}
+
+	// subscribed again on the same node when it is reconnected
+	first.drop()
+	first.expect(t, "subscribe t1 owner1")
+
+	// and moved to the new active node on failover
+	node, err := newNode(second.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.transport.StartContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	c.initNode(node)
+	c.poolMutex.Lock()
+	c.nodes = append(c.nodes, node)
+	c.poolMutex.Unlock()
+	// the first node is gone and can not be reconnected
+	first.server.Listener.Close()
+	first.drop()
+	deadline := time.Now().Add(5 * time.Second)
+	for !c.isActive(node) && time.Now().Before(deadline) {
+		c.failover(context.Background())
+		time.Sleep(50 * time.Millisecond)
+	}
+	if !c.isActive(node) {
+		t.Fatalf("expected failover to the second node, got %+v", c.Nodes())
+	}
+	second.expect(t, "subscribe t1 owner1")
+
+	// not subscribed again after unsubscribing
+	if err := c.UnSubscribeTable("t1", "owner1"); err != nil {
+		t.Fatal(err)
+	}
+	second.expect(t, "unsubscribe t1 owner1")
+	second.drop()
+	time.Sleep(2 * MinReconnectBackoff)
+	second.expectNone(t)
+	if tables := c.Event.GetTableSubscriptions(); len(tables) != 0 {
+		t.Fatalf("expected no table subscription, got %v", tables)
+	}
+}
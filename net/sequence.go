@@ -0,0 +1,107 @@
+package net
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// SequenceFetcher request for the current sequence of an account
+type SequenceFetcher func(ctx context.Context, address string) (uint32, error)
+
+// SequenceManager hands out account sequences locally, so that concurrent
+// submits from the same account don't need an account_info for each tx
+type SequenceManager struct {
+	accounts map[string]*accountSequence
+	fetch    SequenceFetcher
+	mutex    *sync.Mutex
+}
+
+type accountSequence struct {
+	synced bool
+	base   uint32   // the sequence fetched on the last sync
+	next   uint32   // the next sequence never handed out
+	gaps   []uint32 // sequences given back, handed out first
+	mutex  *sync.Mutex
+}
+
+// NewSequenceManager is constructor
+func NewSequenceManager(fetch SequenceFetcher) *SequenceManager {
+	return &SequenceManager{
+		accounts: make(map[string]*accountSequence),
+		fetch:    fetch,
+		mutex:    new(sync.Mutex),
+	}
+}
+
+func (m *SequenceManager) account(address string) *accountSequence {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	acc, ok := m.accounts[address]
+	if !ok {
+		acc = &accountSequence{mutex: new(sync.Mutex)}
+		m.accounts[address] = acc
+	}
+	return acc
+}
+
+// Acquire hand out a sequence for address, the sequence is fetched
+// from the node on the first call or after Reset
+func (m *SequenceManager) Acquire(ctx context.Context, address string) (uint32, error) {
+	acc := m.account(address)
+	acc.mutex.Lock()
+	defer acc.mutex.Unlock()
+	if !acc.synced {
+		seq, err := m.fetch(ctx, address)
+		if err != nil {
+			return 0, err
+		}
+		acc.base = seq
+		acc.next = seq
+		acc.gaps = nil
+		acc.synced = true
+	}
+	if len(acc.gaps) > 0 {
+		seq := acc.gaps[0]
+		acc.gaps = acc.gaps[1:]
+		return seq, nil
+	}
+	seq := acc.next
+	acc.next++
+	return seq, nil
+}
+
+// Release give back a sequence whose tx has not been applied,
+// it fills the gap by being handed out again before the new ones
+func (m *SequenceManager) Release(address string, seq uint32) {
+	acc := m.account(address)
+	acc.mutex.Lock()
+	defer acc.mutex.Unlock()
+	// handed out before the last sync, or never handed out
+	if !acc.synced || seq < acc.base || seq >= acc.next {
+		return
+	}
+	i := sort.Search(len(acc.gaps), func(i int) bool { return acc.gaps[i] >= seq })
+	if i < len(acc.gaps) && acc.gaps[i] == seq {
+		return
+	}
+	acc.gaps = append(acc.gaps, 0)
+	copy(acc.gaps[i+1:], acc.gaps[i:])
+	acc.gaps[i] = seq
+}
+
+// Reset drop the local sequence of address, the next Acquire fetches it again
+func (m *SequenceManager) Reset(address string) {
+	acc := m.account(address)
+	acc.mutex.Lock()
+	acc.synced = false
+	acc.gaps = nil
+	acc.mutex.Unlock()
+}
+
+// ResetAll drop the local sequences of all the accounts
+func (m *SequenceManager) ResetAll() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.accounts = make(map[string]*accountSequence)
+}
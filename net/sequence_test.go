@@ -0,0 +1,79 @@
+package net
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestSequenceManager(t *testing.T) {
+	fetched := 0
+	m := NewSequenceManager(func(ctx context.Context, address string) (uint32, error) {
+		fetched++
+		return 10, nil
+	})
+	const address = "zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh"
+	acquire := func(expect uint32) {
+		t.Helper()
+		seq, err := m.Acquire(context.Background(), address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seq != expect {
+			t.Fatalf("expected sequence %d, got %d", expect, seq)
+		}
+	}
+
+	acquire(10)
+	acquire(11)
+	acquire(12)
+	if fetched != 1 {
+		t.Fatalf("expected 1 fetch, got %d", fetched)
+	}
+
+	// the gaps are filled first, in order
+	m.Release(address, 12)
+	m.Release(address, 10)
+	m.Release(address, 10)
+	m.Release(address, 20)
+	acquire(10)
+	acquire(12)
+	acquire(13)
+
+	m.Reset(address)
+	m.Release(address, 11)
+	acquire(10)
+	if fetched != 2 {
+		t.Fatalf("expected 2 fetches, got %d", fetched)
+	}
+}
+
+func TestSequenceManagerConcurrent(t *testing.T) {
+	m := NewSequenceManager(func(ctx context.Context, address string) (uint32, error) {
+		return 1, nil
+	})
+	const count = 200
+	var wait sync.WaitGroup
+	var mutex sync.Mutex
+	seen := make(map[uint32]bool)
+	for i := 0; i < count; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			seq, err := m.Acquire(context.Background(), "zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mutex.Lock()
+			seen[seq] = true
+			mutex.Unlock()
+		}()
+	}
+	wait.Wait()
+	for seq := uint32(1); seq <= count; seq++ {
+		if !seen[seq] {
+			t.Fatalf("sequence %d not handed out", seq)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package net
 
 import (
+	"encoding/json"
 	"log"
+	"sync"
 
 	"github.com/buger/jsonparser"
 )
@@ -20,6 +22,7 @@ type ServerInfo struct {
 	// Ledgerhash   string
 	// ServerStatus string
 	Updated bool
+	mutex   *sync.RWMutex
 }
 
 //NewServerInfo is constructor
@@ -31,11 +34,14 @@ func NewServerInfo() *ServerInfo {
 		FeeBase:      10,
 		LoadBase:     256,
 		LoadFactor:   256,
+		mutex:        new(sync.RWMutex),
 	}
 }
 
 //Update update ServerInfo from json result
 func (s *ServerInfo) Update(result string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
 	s.GetFieldInt(result, &s.FeeBase, "fee_base")
 	s.GetFieldInt(result, &s.FeeRef, "fee_ref")
@@ -72,8 +78,25 @@ func (s *ServerInfo) GetFieldString(result string, field *string, fieldInJSON st
 	}
 }
 
+//Snapshot return a copy of ServerInfo, the fields are read
+//from the copy while the messages keep updating ServerInfo
+func (s *ServerInfo) Snapshot() ServerInfo {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return *s
+}
+
+//Invalidate mark ServerInfo outdated until the next Update
+func (s *ServerInfo) Invalidate() {
+	s.mutex.Lock()
+	s.Updated = false
+	s.mutex.Unlock()
+}
+
 // ComputeFee compute the basic transaction fee
 func (s *ServerInfo) ComputeFee() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
 	if !s.Updated {
 		return 0
 	}
@@ -82,3 +105,71 @@ func (s *ServerInfo) ComputeFee() int {
 	fee := int(float32(s.FeeBase) * feeUnit * 1.1)
 	return fee
 }
+
+// NodeInfo is the server status returned by server_info
+type NodeInfo struct {
+	BuildVersion     string          `json:"build_version"`
+	ServerState      string          `json:"server_state"`
+	CompleteLedgers  string          `json:"complete_ledgers"`
+	LoadFactor       float64         `json:"load_factor"`
+	Peers            int             `json:"peers"`
+	Uptime           int64           `json:"uptime"`
+	PubkeyNode       string          `json:"pubkey_node"`
+	ValidatedLedger  ValidatedLedger `json:"validated_ledger"`
+	ValidationQuorum int             `json:"validation_quorum"`
+}
+
+// ValidatedLedger is the last validated ledger in server_info
+type ValidatedLedger struct {
+	Seq            int     `json:"seq"`
+	Hash           string  `json:"hash"`
+	Age            int     `json:"age"`
+	BaseFeeZXC     float64 `json:"base_fee_zxc"`
+	ReserveBaseZXC float64 `json:"reserve_base_zxc"`
+	ReserveIncZXC  float64 `json:"reserve_inc_zxc"`
+}
+
+// NewNodeInfo parse the response of server_info
+func NewNodeInfo(response string) (*NodeInfo, error) {
+	info, _, _, err := jsonparser.Get([]byte(response), "result", "info")
+	if err != nil {
+		return nil, err
+	}
+	nodeInfo := &NodeInfo{}
+	err = json.Unmarshal(info, nodeInfo)
+	if err != nil {
+		return nil, err
+	}
+	return nodeInfo, nil
+}
+
+// AccountInfo is the account data returned by account_info
+type AccountInfo struct {
+	Account     string `json:"Account"`
+	Balance     string `json:"Balance"`
+	Sequence    uint32 `json:"Sequence"`
+	OwnerCount  uint32 `json:"OwnerCount"`
+	Flags       uint32 `json:"Flags"`
+	LedgerIndex int    `json:"-"`
+	Validated   bool   `json:"-"`
+}
+
+// NewAccountInfo parse the response of account_info, Balance is in drops
+func NewAccountInfo(response string) (*AccountInfo, error) {
+	data, _, _, err := jsonparser.Get([]byte(response), "result", "account_data")
+	if err != nil {
+		return nil, err
+	}
+	accountInfo := &AccountInfo{}
+	err = json.Unmarshal(data, accountInfo)
+	if err != nil {
+		return nil, err
+	}
+	if index, err := jsonparser.GetInt([]byte(response), "result", "ledger_current_index"); err == nil {
+		accountInfo.LedgerIndex = int(index)
+	} else if index, err := jsonparser.GetInt([]byte(response), "result", "ledger_index"); err == nil {
+		accountInfo.LedgerIndex = int(index)
+	}
+	accountInfo.Validated, _ = jsonparser.GetBoolean([]byte(response), "result", "validated")
+	return accountInfo, nil
+}
@@ -0,0 +1,79 @@
+package net
+
+import (
+	"testing"
+)
+
+func TestNewNodeInfo(t *testing.T) {
+	info, err := NewNodeInfo(`{"id":1,"status":"success","type":"response","result":{"info":{
+		"build_version":"1.1.3","server_state":"full","complete_ledgers":"1-120","load_factor":1.5,
+		"peers":4,"uptime":3600,"pubkey_node":"n9K","validation_quorum":3,
+		"validated_ledger":{"seq":120,"hash":"ABCD","age":2,"base_fee_zxc":1e-05,"reserve_base_zxc":5,"reserve_inc_zxc":1}}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ServerState != "full" || info.CompleteLedgers != "1-120" || info.LoadFactor != 1.5 || info.Peers != 4 || info.ValidationQuorum != 3 {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if info.ValidatedLedger.Seq != 120 || info.ValidatedLedger.Hash != "ABCD" || info.ValidatedLedger.ReserveBaseZXC != 5 {
+		t.Fatalf("unexpected validated ledger %+v", info.ValidatedLedger)
+	}
+	if _, err := NewNodeInfo(`{"result":{"status":"error"}}`); err == nil {
+		t.Fatal("expected a response without info to fail")
+	}
+}
+
+func TestNewAccountInfo(t *testing.T) {
+	info, err := NewAccountInfo(`{"result":{"account_data":{"Account":"zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh",
+		"Balance":"1000000000","Sequence":5,"OwnerCount":2,"Flags":8388608},"ledger_current_index":101}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Balance != "1000000000" || info.Sequence != 5 || info.OwnerCount != 2 || info.Flags != 8388608 {
+		t.Fatalf("unexpected account %+v", info)
+	}
+	if info.LedgerIndex != 101 || info.Validated {
+		t.Fatalf("expected the current ledger 101 not validated, got %+v", info)
+	}
+
+	info, err = NewAccountInfo(`{"result":{"account_data":{"Sequence":6},"ledger_index":100,"validated":true}}`)
+	if err != nil || info.LedgerIndex != 100 || !info.Validated {
+		t.Fatalf("expected the validated ledger 100, got %+v:%v", info, err)
+	}
+	if _, err := NewAccountInfo(`{"result":{"error":"actNotFound"}}`); err == nil {
+		t.Fatal("expected a response without account_data to fail")
+	}
+}
+
+func TestServerInfoSnapshot(t *testing.T) {
+	s := NewServerInfo()
+	if s.Snapshot().Updated || s.ComputeFee() != 0 {
+		t.Fatal("expected no fee before the first update")
+	}
+	s.Update(`{"fee_base":10,"fee_ref":10,"load_base":256,"load_factor":512,"ledger_index":100,"drops_per_byte":1000}`)
+	snapshot := s.Snapshot()
+	if !snapshot.Updated || snapshot.LedgerIndex != 100 || snapshot.DropsPerByte != 1000 || snapshot.LoadFactor != 512 {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+	if fee := s.ComputeFee(); fee != 22 {
+		t.Fatalf("expected the fee raised by the load, got %d", fee)
+	}
+
+	// the snapshot is a copy kept by the later updates
+	s.Update(`{"ledger_index":101}`)
+	if snapshot.LedgerIndex != 100 || s.Snapshot().LedgerIndex != 101 || s.Snapshot().LoadFactor != 512 {
+		t.Fatalf("unexpected snapshots %+v and %+v", snapshot, s.Snapshot())
+	}
+
+	s.Invalidate()
+	if s.Snapshot().Updated || s.ComputeFee() != 0 {
+		t.Fatal("expected ServerInfo outdated after Invalidate")
+	}
+	if s.Snapshot().LedgerIndex != 101 {
+		t.Fatal("expected Invalidate to keep the fields")
+	}
+	s.Update(`{"ledger_index":102}`)
+	if !s.Snapshot().Updated {
+		t.Fatal("expected ServerInfo updated again")
+	}
+}
@@ -0,0 +1,40 @@
+package net
+
+import (
+	"context"
+	"strings"
+)
+
+// Transport is the connection to a node, requests are written to WriteChan
+// and the responses and subscribed messages are read from ReadChan
+// in the format of the websocket api
+type Transport interface {
+	StartContext(ctx context.Context) error
+	Started() bool
+	WriteChan() chan string
+	ReadChan() chan string
+	IsConnected() bool
+	Disconnect() error
+	OnReconnected(cb Reconnected)
+	// Streaming reports whether the messages are pushed by the node,
+	// or emulated by polling without db status and table messages
+	Streaming() bool
+}
+
+// NewTransport creates the transport by the scheme of url,
+// http:// and https:// use the JSON-RPC port, ws:// and wss:// use websocket,
+// an error is returned for invalid options
+func NewTransport(url string, opts *DialOptions) (Transport, error) {
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		h, err := NewHTTPTransport(url, opts)
+		if err != nil {
+			return nil, err
+		}
+		return h, nil
+	}
+	wsc, err := NewWsClientManagerWithOptions(url, ReconnectInterval, opts)
+	if err != nil {
+		return nil, err
+	}
+	return wsc, nil
+}
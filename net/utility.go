@@ -1,41 +1,56 @@
 package net
 
 import (
-	"sync"
-
-	"github.com/buger/jsonparser"
+	"context"
 )
 
 //PrepareTable return the account sequence and table NameInDB
 func PrepareTable(client *Client, name string) (uint32, string, error) {
-	w := new(sync.WaitGroup)
-	w.Add(2)
-	var seq uint32 = 0
-	nameInDB := ""
-	err := error(nil)
-	go func() {
-		defer w.Done()
-		info, errTmp := client.GetAccountInfo(client.Auth.Address)
-		if errTmp != nil {
-			err = errTmp
-			return
-		}
-		sequence, errTmp := jsonparser.GetInt([]byte(info), "result", "account_data", "Sequence")
-		if errTmp != nil {
-			err = errTmp
-			return
-		}
-		seq = uint32(sequence)
-	}()
+	return PrepareTableContext(context.Background(), client, name)
+}
+
+//PrepareTableContext is the same as PrepareTable but bounded by ctx
+func PrepareTableContext(ctx context.Context, client *Client, name string) (uint32, string, error) {
+	type seqResult struct {
+		seq uint32
+		err error
+	}
+	seqChan := make(chan seqResult, 1)
 	go func() {
-		defer w.Done()
-		nameInDBTmp, errTmp := client.GetNameInDB(client.Auth.Owner, name)
-		if errTmp != nil {
-			err = errTmp
-			return
-		}
-		nameInDB = nameInDBTmp
+		seq, err := PrepareAccountContext(ctx, client)
+		seqChan <- seqResult{seq, err}
 	}()
-	w.Wait()
-	return seq, nameInDB, err
+	nameInDB, err := client.GetNameInDBContext(ctx, client.Auth.Owner, name)
+	account := <-seqChan
+	if account.err != nil {
+		return 0, "", account.err
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	return account.seq, nameInDB, nil
+}
+
+//PrepareAccount return the sequence of the operating account,
+//the sequence is handed out by client.Sequences
+func PrepareAccount(client *Client) (uint32, error) {
+	return PrepareAccountContext(context.Background(), client)
+}
+
+//PrepareAccountContext is the same as PrepareAccount but bounded by ctx
+func PrepareAccountContext(ctx context.Context, client *Client) (uint32, error) {
+	return client.Sequences.Acquire(ctx, client.Auth.Address)
+}
+
+// fetchSequence request for the sequence of an account by account_info
+func (c *Client) fetchSequence(ctx context.Context, address string) (uint32, error) {
+	response, err := c.GetAccountInfoContext(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+	info, err := NewAccountInfo(response)
+	if err != nil {
+		return 0, err
+	}
+	return info.Sequence, nil
 }
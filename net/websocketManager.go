@@ -1,190 +1,312 @@
 package net
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"sync"
 	"time"
 
-	"github.com/ChainSQL/go-chainsql-api/util"
 	"github.com/gorilla/websocket"
 )
 
+//MinReconnectBackoff is the delay before the first reconnection,
+//it doubles after each failed reconnection up to the timeout of the manager
+const MinReconnectBackoff = 500 * time.Millisecond
+
+//ErrClosed is returned when starting a closed WebsocketManager
+var ErrClosed = errors.New("websocket manager closed")
+
 type Reconnected func()
 
-// WebsocketManager is a websocket client manager
+// WebsocketManager is a websocket client manager, the messages written to
+// WriteChan are sent by a single writer goroutine, the received messages are
+// pushed to ReadChan, which is closed once Close stops all the goroutines
 type WebsocketManager struct {
 	conn          *websocket.Conn
 	url           string
 	sendMsgChan   chan string
 	recvMsgChan   chan string
 	isAlive       bool
-	timeout       int // used for reconnecting
-	muxRead       *sync.Mutex
-	muxWrite      *sync.Mutex
-	muxConnect    *sync.Mutex
+	started       bool
+	timeout       int // the max backoff in seconds for reconnecting
+	mutex         *sync.Mutex
 	onReconnected Reconnected
-	first_connect bool
+	dialer        *websocket.Dialer
+	header        http.Header
+	writeTimeout  time.Duration
+	done          chan struct{}
+	closeOnce     *sync.Once
+	routines      *sync.WaitGroup
 }
 
 // NewWsClientManager is a constructor
 func NewWsClientManager(url string, timeout int) *WebsocketManager {
-	var sendChan = make(chan string, 1024)
-	var recvChan = make(chan string, 1024)
-	var conn *websocket.Conn
-	return &WebsocketManager{
-		url:           url,
-		conn:          conn,
-		sendMsgChan:   sendChan,
-		recvMsgChan:   recvChan,
-		isAlive:       false,
-		timeout:       timeout,
-		muxRead:       new(sync.Mutex),
-		muxWrite:      new(sync.Mutex),
-		muxConnect:    new(sync.Mutex),
-		onReconnected: nil,
-		first_connect: true,
+	// the default options never fail
+	wsc, _ := NewWsClientManagerWithOptions(url, timeout, nil)
+	return wsc
+}
+
+// NewWsClientManagerWithOptions is a constructor with the TLS, proxy and timeout options,
+// an error is returned for invalid options like a bad ProxyURL
+func NewWsClientManagerWithOptions(url string, timeout int, opts *DialOptions) (*WebsocketManager, error) {
+	dialer, err := opts.dialer()
+	if err != nil {
+		return nil, err
 	}
+	return &WebsocketManager{
+		url:          url,
+		sendMsgChan:  make(chan string, 1024),
+		recvMsgChan:  make(chan string, 1024),
+		timeout:      timeout,
+		mutex:        new(sync.Mutex),
+		dialer:       dialer,
+		header:       opts.header(),
+		writeTimeout: opts.writeTimeout(),
+		done:         make(chan struct{}),
+		closeOnce:    new(sync.Once),
+		routines:     new(sync.WaitGroup),
+	}, nil
 }
 
 // 链接服务端
-func (wsc *WebsocketManager) dail() error {
-	var err error
-	log.Printf("connecting to %s", wsc.url)
-	websocket.DefaultDialer.HandshakeTimeout = util.DIAL_TIMEOUT * time.Second
-	wsc.conn, _, err = websocket.DefaultDialer.Dial(wsc.url, nil)
+func (wsc *WebsocketManager) dail(ctx context.Context) (*websocket.Conn, error) {
+	wsc.mutex.Lock()
+	url := wsc.url
+	wsc.mutex.Unlock()
+	log.Printf("connecting to %s", url)
+	conn, _, err := wsc.dialer.DialContext(ctx, url, wsc.header)
 	if err != nil {
-		log.Printf("connecting to %s failed,err:%s", wsc.url, err.Error())
-		return err
+		log.Printf("connecting to %s failed,err:%s", url, err.Error())
+		return nil, err
 	}
-	wsc.isAlive = true
-	log.Printf("connecting to %s success!", wsc.url)
-	return nil
+	log.Printf("connecting to %s success!", url)
+	return conn, nil
 }
 
+//Disconnect is the same as Close
 func (wsc *WebsocketManager) Disconnect() error {
-	wsc.muxWrite.Lock()
-	wsc.muxConnect.Lock()
+	return wsc.Close()
+}
 
-	if wsc.conn != nil {
-		err := wsc.conn.Close()
-		if err != nil {
-			return err
+//Close close the connection and wait for all the goroutines to exit,
+//the manager can not be started again
+func (wsc *WebsocketManager) Close() error {
+	var err error
+	wsc.closeOnce.Do(func() {
+		close(wsc.done)
+		wsc.mutex.Lock()
+		if wsc.conn != nil {
+			err = wsc.conn.Close()
 		}
-		wsc.conn = nil
-	}
-
-	wsc.isAlive = false
+		wsc.isAlive = false
+		wsc.mutex.Unlock()
+		wsc.routines.Wait()
+		close(wsc.recvMsgChan)
+	})
+	return err
+}
 
-	wsc.muxConnect.Unlock()
-	wsc.muxWrite.Unlock()
-	return nil
+func (wsc *WebsocketManager) closed() bool {
+	select {
+	case <-wsc.done:
+		return true
+	default:
+		return false
+	}
 }
 
 func (wsc *WebsocketManager) SetUrl(url string) {
+	wsc.mutex.Lock()
 	wsc.url = url
-}
-
-// 发送消息
-func (wsc *WebsocketManager) sendMsgThread() {
-	go func() {
-		for {
-			if wsc.isAlive {
-				msg := <-wsc.sendMsgChan
-
-				if wsc.conn != nil {
-					wsc.muxWrite.Lock()
-					// wsc.conn.SetWriteDeadline(time.Now().Add(time.Duration(wsc.timeout)))
-					err := wsc.conn.WriteMessage(websocket.TextMessage, []byte(msg))
-					wsc.muxWrite.Unlock()
-					if err != nil {
-						wsc.close()
-						log.Println("write:", err)
-						wsc.sendMsgChan <- msg
-						wsc.isAlive = false
-						// break
-					}
-				}
-			} else {
-				time.Sleep(time.Second * 1)
-			}
-		}
-	}()
+	wsc.mutex.Unlock()
 }
 
 func (wsc *WebsocketManager) OnReconnected(cb Reconnected) {
+	wsc.mutex.Lock()
 	wsc.onReconnected = cb
+	wsc.mutex.Unlock()
+}
+
+// setConn replace the connection, false is returned if the manager is closed
+func (wsc *WebsocketManager) setConn(conn *websocket.Conn) bool {
+	wsc.mutex.Lock()
+	defer wsc.mutex.Unlock()
+	if wsc.closed() {
+		return false
+	}
+	wsc.conn = conn
+	wsc.isAlive = conn != nil
+	return true
 }
 
-// 读取消息
-func (wsc *WebsocketManager) readMsgThread() {
-	go func() {
-		for {
-			if wsc.conn != nil && wsc.isAlive {
-				wsc.muxRead.Lock()
-				_, message, err := wsc.conn.ReadMessage()
-				wsc.muxRead.Unlock()
-				if err != nil {
-					wsc.close()
-					log.Println("read:", err)
-					wsc.isAlive = false
-					// break
-				} else {
-					// log.Printf("recv: %s", message)
-					// 需要读取数据，不然会阻塞
-					wsc.recvMsgChan <- string(message)
-				}
-			} else {
-				time.Sleep(time.Second * 1)
+// run is the single writer of the connections, it reconnects
+// with backoff when the connection is lost until the manager is closed
+func (wsc *WebsocketManager) run(conn *websocket.Conn) {
+	defer wsc.routines.Done()
+	var pending *string
+	for {
+		lost := make(chan struct{})
+		wsc.routines.Add(1)
+		go wsc.readMsgThread(conn, lost)
+		pending = wsc.sendMsgThread(conn, lost, pending)
+		conn.Close()
+		wsc.setConn(nil)
+		<-lost
+
+		conn = wsc.reconnect()
+		if conn == nil {
+			return
+		}
+		wsc.mutex.Lock()
+		cb := wsc.onReconnected
+		wsc.mutex.Unlock()
+		if cb != nil {
+			// the callback sends requests, which need this goroutine to write them
+			go cb()
+		}
+	}
+}
+
+// 发送消息, return the message failed to send when the connection is lost
+func (wsc *WebsocketManager) sendMsgThread(conn *websocket.Conn, lost chan struct{}, pending *string) *string {
+	for {
+		var msg string
+		if pending != nil {
+			msg = *pending
+			pending = nil
+		} else {
+			select {
+			case msg = <-wsc.sendMsgChan:
+			case <-lost:
+				return nil
+			case <-wsc.done:
+				return nil
 			}
 		}
-	}()
+		if wsc.writeTimeout > 0 {
+			conn.SetWriteDeadline(time.Now().Add(wsc.writeTimeout))
+		}
+		err := conn.WriteMessage(websocket.TextMessage, []byte(msg))
+		if err != nil {
+			log.Println("write:", err)
+			return &msg
+		}
+	}
 }
 
-func (wsc *WebsocketManager) close() {
-	if wsc.isAlive {
-		defer wsc.conn.Close()
-		wsc.isAlive = false
+// 读取消息, lost is closed when the connection is lost
+func (wsc *WebsocketManager) readMsgThread(conn *websocket.Conn, lost chan struct{}) {
+	defer wsc.routines.Done()
+	defer close(lost)
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if !wsc.closed() {
+				log.Println("read:", err)
+			}
+			return
+		}
+		select {
+		case wsc.recvMsgChan <- string(message):
+		case <-wsc.done:
+			return
+		}
 	}
 }
 
-func (wsc *WebsocketManager) checkReconnect() {
-	go func() {
-		for {
-			if !wsc.isAlive {
-				log.Println("checkReconnect ws disconnected,reconnect!")
-				wsc.muxConnect.Lock()
-				err := wsc.connectAndRun()
-				wsc.muxConnect.Unlock()
-				if err == nil && wsc.onReconnected != nil {
-					wsc.onReconnected()
-				}
+// reconnect dial with exponential backoff until success,
+// nil is returned if the manager is closed
+func (wsc *WebsocketManager) reconnect() *websocket.Conn {
+	backoff := MinReconnectBackoff
+	maxBackoff := time.Duration(wsc.timeout) * time.Second
+	if maxBackoff < MinReconnectBackoff {
+		maxBackoff = MinReconnectBackoff
+	}
+	for {
+		if wsc.closed() {
+			return nil
+		}
+		log.Printf("ws disconnected,reconnect in %s!", backoff)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-wsc.done:
+			timer.Stop()
+			return nil
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-wsc.done:
+				cancel()
+			case <-ctx.Done():
 			}
-			time.Sleep(time.Second * time.Duration(wsc.timeout))
+		}()
+		conn, err := wsc.dail(ctx)
+		cancel()
+		if err == nil {
+			if !wsc.setConn(conn) {
+				conn.Close()
+				return nil
+			}
+			return conn
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
 		}
-	}()
+	}
 }
 
 //Start 开启服务并重连
 func (wsc *WebsocketManager) Start() error {
-	err := wsc.connectAndRun()
-	return err
+	return wsc.StartContext(context.Background())
 }
 
-func (wsc *WebsocketManager) connectAndRun() error {
-	err := wsc.dail()
-	if err == nil && wsc.first_connect {
-		wsc.sendMsgThread()
-		wsc.readMsgThread()
-		wsc.checkReconnect()
-		wsc.first_connect = false
+//StartContext is the same as Start but the first dial is bounded by ctx
+func (wsc *WebsocketManager) StartContext(ctx context.Context) error {
+	if wsc.closed() {
+		return ErrClosed
 	}
-	return err
+	conn, err := wsc.dail(ctx)
+	if err != nil {
+		return err
+	}
+	wsc.mutex.Lock()
+	defer wsc.mutex.Unlock()
+	if wsc.closed() || wsc.started {
+		conn.Close()
+		if wsc.started {
+			return nil
+		}
+		return ErrClosed
+	}
+	wsc.conn = conn
+	wsc.isAlive = true
+	wsc.started = true
+	wsc.routines.Add(1)
+	go wsc.run(conn)
+	return nil
+}
+
+//Started reports whether the first connection has succeeded
+func (wsc *WebsocketManager) Started() bool {
+	wsc.mutex.Lock()
+	defer wsc.mutex.Unlock()
+	return wsc.started
+}
+
+//Streaming is true, the messages are pushed by the node
+func (wsc *WebsocketManager) Streaming() bool {
+	return true
 }
 
 //Print print the channel buffer size
 func (wsc *WebsocketManager) Print() {
-	log.Println("read buffer size: %n", len(wsc.recvMsgChan))
-	log.Println("write buffer size: %n ", len(wsc.sendMsgChan))
+	log.Printf("read buffer size: %d\n", len(wsc.recvMsgChan))
+	log.Printf("write buffer size: %d\n", len(wsc.sendMsgChan))
 }
 
 //WriteChan return the write channel
@@ -192,19 +314,13 @@ func (wsc *WebsocketManager) WriteChan() chan string {
 	return wsc.sendMsgChan
 }
 
-//ReadChan return the channel used to read
+//ReadChan return the channel used to read, it is closed after Close
 func (wsc *WebsocketManager) ReadChan() chan string {
 	return wsc.recvMsgChan
 }
 
 func (wsc *WebsocketManager) IsConnected() bool {
+	wsc.mutex.Lock()
+	defer wsc.mutex.Unlock()
 	return wsc.isAlive
 }
-
-// func main() {
-//     wsc := NewWsClientManager("192.168.12.15", "10086", "/v1", 10)
-//     wsc.start()
-//     var w1 sync.WaitGroup
-//     w1.Add(1)
-//     w1.Wait()
-//	}
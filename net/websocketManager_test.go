@@ -0,0 +1,140 @@
+package net
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newEchoServer echo the messages back, drop closes all the connections
+func newEchoServer(t *testing.T) (*httptest.Server, func()) {
+	upgrader := websocket.Upgrader{}
+	var mutex sync.Mutex
+	var conns []*websocket.Conn
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade error:%s", err)
+			return
+		}
+		mutex.Lock()
+		conns = append(conns, conn)
+		mutex.Unlock()
+		for {
+			mt, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(mt, msg)
+		}
+	}))
+	drop := func() {
+		mutex.Lock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+		conns = nil
+		mutex.Unlock()
+	}
+	return server, drop
+}
+
+func echo(t *testing.T, wsc *WebsocketManager, msg string) {
+	wsc.WriteChan() <- msg
+	select {
+	case got := <-wsc.ReadChan():
+		if got != msg {
+			t.Fatalf("expected %s, got %s", msg, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no echo of %s", msg)
+	}
+}
+
+func TestWebsocketManagerReconnect(t *testing.T) {
+	server, drop := newEchoServer(t)
+	defer server.Close()
+	wsc := NewWsClientManager("ws"+strings.TrimPrefix(server.URL, "http"), 1)
+	reconnected := make(chan struct{}, 1)
+	wsc.OnReconnected(func() {
+		reconnected <- struct{}{}
+	})
+	if err := wsc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	echo(t, wsc, "1")
+
+	drop()
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("not reconnected")
+	}
+	if !wsc.IsConnected() {
+		t.Fatal("expected connected after reconnecting")
+	}
+	echo(t, wsc, "2")
+
+	if err := wsc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// ReadChan is closed after all the goroutines exit
+	if _, ok := <-wsc.ReadChan(); ok {
+		t.Fatal("expected ReadChan closed")
+	}
+	if wsc.IsConnected() || wsc.Close() != nil || wsc.Start() != ErrClosed {
+		t.Fatal("expected the manager closed")
+	}
+}
+
+func TestWebsocketManagerCloseWhileReconnecting(t *testing.T) {
+	server, drop := newEchoServer(t)
+	wsc := NewWsClientManager("ws"+strings.TrimPrefix(server.URL, "http"), 60)
+	if err := wsc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+	drop()
+	for i := 0; i < 100 && wsc.IsConnected(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	done := make(chan struct{})
+	go func() {
+		wsc.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked by the reconnection")
+	}
+}
+
+func TestNextIDConcurrent(t *testing.T) {
+	c := NewClient()
+	ids := make(chan int64, 1000)
+	wait := new(sync.WaitGroup)
+	for i := 0; i < 10; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for j := 0; j < 100; j++ {
+				ids <- c.nextID()
+			}
+		}()
+	}
+	wait.Wait()
+	close(ids)
+	seen := make(map[int64]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = true
+	}
+}
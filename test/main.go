@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/ChainSQL/go-chainsql-api/core"
+	"github.com/ChainSQL/go-chainsql-api/crypto"
+	"github.com/ChainSQL/go-chainsql-api/keystore"
 	"github.com/buger/jsonparser"
 	"github.com/gorilla/websocket"
 )
@@ -22,6 +25,13 @@ var tableName = "hello2"
 func main() {
 	c := core.NewChainsql()
 	// err := c.Connect("ws://127.0.0.1:6006")
+	// // several nodes for failover
+	// err := c.Connect("ws://127.0.0.1:6006", "ws://127.0.0.1:6007")
+	// // JSON-RPC without websocket
+	// err := c.Connect("http://127.0.0.1:5005")
+	// // wss with a custom CA
+	// roots, _ := net.LoadCertPool("ca.pem")
+	// err := c.ConnectWithOptions(context.Background(), &net.DialOptions{RootCAs: roots}, "wss://127.0.0.1:6006")
 	// log.Println("IsConnected:", c.IsConnected())
 	// if err != nil {
 	// 	log.Println(err)
@@ -40,10 +50,30 @@ func main() {
 	// c.Use(root.address)
 
 	// // testSubLedger(c)
+	// testSubTable(c, root.address)
 	testGenerateAccount(c)
+	// testCreateTable(c)
+	// testRenameTable(c)
+	// testDropTable(c)
+	// testGrant(c, user.address)
 	// testInsert(c)
+	// testUpdate(c)
+	// testDelete(c)
+	// testTransaction(c)
+	// testPay(c, user.address)
+	// testIssueCurrency(c, root, user)
+	// testSchema(c)
+	// testGetInfo(c, root.address)
 	// testGetLedger(c)
+	// testContext(c)
 	// testSignPlainText(c)
+	// testSignOffline(c, user.address)
+	// testCommandSigner(c, root.address)
+	// testKeystore(c, root)
+	// testConfidentialTable(c, user)
+
+	// // signers are 3 accounts other than root
+	// testMultiSign(c, root, signers)
 
 	// testGetTableData(c)
 
@@ -65,12 +95,61 @@ func testGenerateAccount(c *core.Chainsql) {
 
 	//Recreate account using the privateKey
 	privateKey, err := jsonparser.GetString([]byte(accStr), "privateKey")
-	accStr, err = c.GenerateAccount(string(privateKey))
+	accStr, err = c.GenerateAccount(crypto.WithSecret(privateKey))
 	if err != nil {
 		log.Println(err)
 		return
 	}
 	log.Println(accStr)
+
+	//Ed25519 account, the secret tells its algorithm
+	accStr, err = c.GenerateAccount(crypto.WithAlgorithm(crypto.Ed25519))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Println(accStr)
+
+	//GM account of the nodes in GM mode
+	accStr, err = c.GenerateAccount(crypto.WithAlgorithm(crypto.SoftGM))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Println(accStr)
+}
+
+func testCreateTable(c *core.Chainsql) {
+	var raw = []byte(`[
+		{"field":"id","type":"int","length":11,"PK":1,"NN":1},
+		{"field":"name","type":"varchar","length":50,"default":null},
+		{"field":"age","type":"int"}
+	]`)
+	ret := c.CreateTable(tableName, string(raw)).Submit("db_success")
+	log.Println(ret)
+}
+
+func testRenameTable(c *core.Chainsql) {
+	ret := c.RenameTable(tableName, tableName+"_new").Submit("db_success")
+	log.Println(ret)
+}
+
+func testDropTable(c *core.Chainsql) {
+	ret := c.DropTable(tableName).Submit("db_success")
+	log.Println(ret)
+}
+
+func testGrant(c *core.Chainsql, user string) {
+	var flags = []byte(`{"select":true,"insert":true,"update":false,"delete":false}`)
+	ret := c.Grant(tableName, user, string(flags)).Submit("validate_success")
+	log.Println(ret)
+
+	auth, err := c.GetTableAuth("zHb9CJAWyB4zj91VRWn96DkukG4bwdtyTh", tableName)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Printf("GetTableAuth:%s\n", auth)
 }
 
 func testInsert(c *core.Chainsql) {
@@ -79,6 +158,28 @@ func testInsert(c *core.Chainsql) {
 	log.Println(ret)
 }
 
+func testUpdate(c *core.Chainsql) {
+	var set = []byte(`{"age":20}`)
+	var where = []byte(`{"id":1}`)
+	ret := c.Table(tableName).Update(string(set), string(where)).Submit("db_success")
+	log.Println(ret)
+}
+
+func testDelete(c *core.Chainsql) {
+	var where = []byte(`{"id":1}`)
+	ret := c.Table(tableName).Delete(string(where)).Submit("db_success")
+	log.Println(ret)
+}
+
+func testTransaction(c *core.Chainsql) {
+	c.BeginTran()
+	c.Table(tableName).Insert(`[{"id":2,"name":"peersafe","age":10}]`)
+	c.Table(tableName).Update(`{"age":11}`, `{"id":2}`)
+	c.Table(tableName).Delete(`{"id":1}`)
+	ret := c.CommitTran().Submit("db_success")
+	log.Println(ret)
+}
+
 func testGetTableData(c *core.Chainsql) {
 	//Test withfields
 	log.Println("IsConnected:", c.IsConnected())
@@ -133,6 +234,172 @@ func testGetBySqlUser(c *core.Chainsql) {
 	}
 }
 
+func testPay(c *core.Chainsql, destination string) {
+	ret := c.Pay(destination, "100").Memo("test", "hello", "text/plain").Submit("validate_success")
+	log.Println(ret)
+
+	c.PayDrops(destination, 1000000).DestinationTag(1).SubmitAsync(func(msg string) {
+		log.Printf("PayDrops:%s\n", msg)
+	})
+}
+
+func testSignOffline(c *core.Chainsql, destination string) {
+	// on the air-gapped host, As is enough without Connect
+	signed, err := c.Pay(destination, "100").SignOffline(&core.OfflineParams{
+		Sequence:           10,
+		Fee:                12,
+		LastLedgerSequence: 1000,
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Printf("hash:%s blob:%s\n", signed.Hash, signed.TxBlob)
+
+	// on the connected host
+	ret := c.SubmitSigned(signed.TxBlob, "validate_success")
+	log.Println(ret)
+}
+
+func testCommandSigner(c *core.Chainsql, address string) {
+	// kms-signer bridges to the KMS keeping the key of address,
+	// it answers the requests of crypto.CommandSigner on stdin and stdout
+	signer := crypto.NewCommandSigner("/usr/local/bin/kms-signer", "--key", address)
+	signer.Timeout = 5 * time.Second
+	c.AsSigner(address, signer)
+	log.Println(c.Pay("zBonp9s7isAaDUPcfrFfYjNnhgeznoBHxF", "10").Submit("validate_success"))
+}
+
+func testKeystore(c *core.Chainsql, account Account) {
+	ks, err := keystore.New("./keystore")
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	// done once, the secret is not needed afterwards
+	if _, err := ks.Import(account.secret, "password"); err != nil {
+		log.Println(err)
+	}
+	if err := c.AsKeystore("./keystore", account.address, "password", time.Hour); err != nil {
+		log.Println(err)
+		return
+	}
+	log.Println(c.Pay("zBonp9s7isAaDUPcfrFfYjNnhgeznoBHxF", "10").Submit("validate_success"))
+}
+
+func testConfidentialTable(c *core.Chainsql, user Account) {
+	name := "pii"
+	var raw = []byte(`[
+		{"field":"id","type":"varchar","length":128,"PK":1},
+		{"field":"name","type":"text"},
+		{"field":"phone","type":"text"}
+	]`)
+	ret := c.CreateTableWithOptions(name, string(raw), &core.TableOptions{Confidential: true}).Submit("db_success")
+	log.Println(ret)
+	ret = c.Table(name).Insert(`[{"id":1,"name":"echo","phone":"13800000000"}]`).Submit("db_success")
+	log.Println(ret)
+
+	// the user decrypts the table by the token wrapped with its public key
+	keys, err := c.GenerateAccountKeys(crypto.WithSecret(user.secret))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	ret = c.GrantWithPublicKey(name, user.address, keys.PublicKey, `{"select":true}`).Submit("validate_success")
+	log.Println(ret)
+
+	rows, err := c.Table(name).Get(`{"id":1}`).Request()
+	log.Println(rows, err)
+}
+
+func testMultiSign(c *core.Chainsql, owner Account, signers []Account) {
+	c.As(owner.address, owner.secret)
+	weights := map[string]uint16{}
+	for _, signer := range signers {
+		weights[signer.address] = 1
+	}
+	log.Println(c.SignerListSet(2, weights).Submit("validate_success"))
+
+	// 2 of the 3 signers approve the insert
+	prepared, err := c.Table("treasury").Insert(`[{"id":1,"amount":100}]`).PrepareMultiSign(2)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	var blobs []string
+	for _, signer := range signers[:2] {
+		blob, err := core.MultiSignBlob(prepared, signer.address, signer.secret)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		blobs = append(blobs, blob)
+	}
+	signed, err := core.CombineMultiSigned(blobs...)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Println(c.SubmitMultiSigned(signed.TxBlob, "db_success"))
+}
+
+func testIssueCurrency(c *core.Chainsql, issuer Account, holder Account) {
+	c.As(issuer.address, issuer.secret)
+	log.Println(c.SetDefaultRipple(true).Submit("validate_success"))
+	log.Println(c.SetTransferRate("1.002").Submit("validate_success"))
+
+	c.As(holder.address, holder.secret)
+	log.Println(c.TrustSet("10000/USD/" + issuer.address).Submit("validate_success"))
+
+	c.As(issuer.address, issuer.secret)
+	ret := c.Pay(holder.address, "100/USD/"+issuer.address).SendMax("101/USD/" + issuer.address).Submit("validate_success")
+	log.Println(ret)
+}
+
+func testSchema(c *core.Chainsql) {
+	var schemaInfo = []byte(`{
+		"SchemaName":"hello",
+		"WithState":false,
+		"Validators":[{"Validator":{"PublicKey":"02BD87A95F549ECF607D6AE3AEC4C95D0BFF0F49309B4E7A9F15B842EB62A8ED1B"}}],
+		"PeerList":[{"Peer":{"Endpoint":"127.0.0.1:15125"}}]
+	}`)
+	ret := c.CreateSchema(string(schemaInfo)).Submit("validate_success")
+	log.Println(ret)
+
+	list, err := c.GetSchemaList(`{"running":true}`)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Printf("GetSchemaList:%s\n", list)
+	schemaID, err := jsonparser.GetString([]byte(list), "[0]", "schema_id")
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	c.SetSchema(schemaID)
+	testGetTableData(c)
+	c.SetSchema("")
+}
+
+func testGetInfo(c *core.Chainsql, address string) {
+	serverInfo, err := c.GetServerInfoResult()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Printf("server_state:%s complete_ledgers:%s validated_ledger:%d load_factor:%f\n",
+		serverInfo.ServerState, serverInfo.CompleteLedgers, serverInfo.ValidatedLedger.Seq, serverInfo.LoadFactor)
+
+	accountInfo, err := c.GetAccountInfoResult(address)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Printf("balance:%s sequence:%d owner_count:%d flags:%d\n",
+		accountInfo.Balance, accountInfo.Sequence, accountInfo.OwnerCount, accountInfo.Flags)
+}
+
 func testGetLedger(c *core.Chainsql) {
 	for i := 20; i < 25; i++ {
 		ledger := c.GetLedger(i)
@@ -140,6 +407,25 @@ func testGetLedger(c *core.Chainsql) {
 	}
 }
 
+func testContext(c *core.Chainsql) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	var data = []byte(`[{"id":3,"name":"context","age":30}]`)
+	ret, err := c.Table(tableName).Insert(string(data)).SubmitContext(ctx, "db_success")
+	if err != nil {
+		log.Printf("SubmitContext:%s, %s\n", err, ret)
+		return
+	}
+	log.Println(ret)
+
+	rows, err := c.Table(tableName).Get("").RequestContext(ctx)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Println(rows)
+}
+
 func testSubLedger(c *core.Chainsql) {
 	go func() {
 		c.OnLedgerClosed(func(msg string) {
@@ -148,6 +434,15 @@ func testSubLedger(c *core.Chainsql) {
 	}()
 }
 
+func testSubTable(c *core.Chainsql, owner string) {
+	err := c.SubscribeTable(owner, tableName, func(msg string) {
+		log.Printf("OnTableMsg:%s\n", msg)
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
 func testSignPlainText(c *core.Chainsql) {
 	signed, err := c.SignPlainData("xnoPBzXtMeMyMHUVTgbuqAfg1SUTb", "HelloWorld")
 	if err != nil {
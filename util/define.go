@@ -1,5 +1,17 @@
 package util
 
+const (
+	TCreate = 1
+	TDrop   = 2
+	TRename = 3
+	TGrant  = 11
+)
+
+const (
+	SchemaAdd = 1
+	SchemaDel = 2
+)
+
 const (
 	RInsert = 6
 	RUpdate = 8
@@ -25,6 +37,7 @@ const (
 	SendError       = "send_error"
 	ValidateError   = "validate_error"
 	ValidateTimeout = "validate_timeout"
+	DbTimeout       = "db_timeout"
 )
 
 const (
@@ -25,19 +25,13 @@ func IsChainsqlType(t string) bool {
 
 //SignPlainData sign a plain text and return the signature
 func SignPlainData(privateKey string, data string) (string, error) {
-	seed, err := crypto.NewRippleHash(privateKey)
-	if err != nil {
-		return "", err
-	}
-	key, err := crypto.NewECDSAKey(seed.Payload())
+	key, _, err := crypto.NewKeyFromSecret(privateKey)
 	if err != nil {
 		log.Println(err)
 		return "", err
 	}
-	sequenceZero := uint32(0)
-	private := key.Private(&sequenceZero)
 	hash := crypto.Sha512Half([]byte(data))
-	sigBytes, err := crypto.Sign(private, hash, nil)
+	sigBytes, err := crypto.SignWithKey(key, crypto.AccountKeySequence(key), hash, []byte(data))
 	if err != nil {
 		log.Println(err)
 		return "", err